@@ -0,0 +1,289 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/luckyhut/gator/config"
+	"github.com/luckyhut/gator/database"
+	"github.com/luckyhut/gator/logging"
+	"github.com/luckyhut/gator/metrics"
+	"log/slog"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// how long an in-flight fetch may keep going after agg is told to stop
+const shutdownGrace = 10 * time.Second
+
+// aggTotals is what agg reports when it finishes
+type aggTotals struct {
+	started  time.Time
+	fetches  int
+	failures int
+	newPosts int
+	updated  int
+}
+
+// with --once and no age given, feeds not fetched within this long are due
+const defaultDueAfter = time.Hour
+
+// exit codes for agg --once and refresh
+const (
+	exitFeedsFailed = 2
+	exitInterrupted = 130
+)
+
+func HandlerAgg(s *State, cmd Command) error {
+	flags := flag.NewFlagSet("agg", flag.ContinueOnError)
+	once := flags.Bool("once", false, "fetch every due feed once, then exit")
+	all := flags.Bool("all", false, "with --once, fetch every feed whether it is due or not")
+	metricsAddr := flags.String("metrics", "", "serve Prometheus metrics on this address, like :9090")
+	err := flags.Parse(cmd.Args)
+	if err != nil {
+		return err
+	}
+
+	// SIGINT and SIGTERM stop agg, SIGHUP reloads the config file
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// fetches run on their own context so they get shutdownGrace to finish
+	fetchCtx, cancelFetches := graceContext(ctx, shutdownGrace)
+	defer cancelFetches()
+
+	if *once {
+		if *metricsAddr != "" {
+			stopMetrics, err := serveMetrics(func() database.Querier { return s.Db }, *metricsAddr)
+			if err != nil {
+				return err
+			}
+			defer stopMetrics()
+		}
+		return aggOnce(ctx, fetchCtx, s, *all, flags.Args())
+	}
+	if *all {
+		return errors.New("--all only works with --once")
+	}
+
+	if flags.NArg() < 1 {
+		return errors.New("Not enough arguments")
+	}
+	duration, err := time.ParseDuration(flags.Arg(0))
+	if err != nil {
+		return errors.New("Error parsing duration")
+	}
+
+	a := newAggregator(s, fetchCtx, duration)
+	if *metricsAddr != "" {
+		stopMetrics, err := serveMetrics(a.queries, *metricsAddr)
+		if err != nil {
+			return err
+		}
+		defer stopMetrics()
+	}
+	return a.run(ctx)
+}
+
+// aggOnce fetches every due feed, or every feed with all, one after another and returns.
+// The optional argument is how long since its last fetch a feed becomes due.
+func aggOnce(ctx, fetchCtx context.Context, s *State, all bool, args []string) error {
+	dueAfter := defaultDueAfter
+	if len(args) > 0 {
+		var err error
+		dueAfter, err = time.ParseDuration(args[0])
+		if err != nil {
+			return errors.New("Error parsing duration")
+		}
+	}
+	dueBefore := time.Now().UTC().Add(-dueAfter)
+	if all {
+		dueBefore = time.Now().UTC()
+	}
+
+	feeds, err := s.Db.GetFeedsToFetch(ctx, dueBefore)
+	if err != nil {
+		return errors.New("Error getting feeds from database")
+	}
+
+	totals := &aggTotals{started: time.Now()}
+	for i := range feeds {
+		if ctx.Err() != nil {
+			break
+		}
+		feed, err := claimFeed(ctx, s, feeds[i].ID)
+		if errors.Is(err, errFeedLeased) {
+			fmt.Printf("skipped %s: %v\n", feedName(&feeds[i]), err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		stats, err := fetchAndStore(fetchCtx, s, &feed)
+		totals.add(stats, err)
+		printFetch(&feed, stats, err)
+	}
+	totals.print()
+
+	if ctx.Err() != nil {
+		return &ExitError{Code: exitInterrupted, Err: errors.New("Interrupted")}
+	}
+	if totals.failures > 0 {
+		return &ExitError{Code: exitFeedsFailed, Err: fmt.Errorf("%d of %d feeds failed", totals.failures, totals.fetches)}
+	}
+	return nil
+}
+
+// HandlerRefresh fetches a single feed right away
+func HandlerRefresh(s *State, cmd Command) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Must include a feed url with this command")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	feed, err := findFeed(ctx, s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	feed, err = claimFeed(ctx, s, feed.ID)
+	if err != nil {
+		return err
+	}
+	stats, err := fetchAndStore(ctx, s, &feed)
+	if err != nil {
+		return &ExitError{Code: exitFeedsFailed, Err: err}
+	}
+	printFetch(&feed, stats, nil)
+	return nil
+}
+
+func feedName(feed *database.Feed) string {
+	if feed.Name.String == "" {
+		return feed.Url.String
+	}
+	return feed.Name.String
+}
+
+func printFetch(feed *database.Feed, stats *fetchStats, err error) {
+	name := feedName(feed)
+	if err != nil {
+		fmt.Printf("failed  %s: %v\n", name, err)
+		return
+	}
+	fmt.Printf("ok      %s: %d new, %d updated, %d skipped\n", name, stats.newItems, stats.updated, stats.skipped)
+}
+
+// serveMetrics starts an HTTP listener for /metrics and returns a function that stops it
+func serveMetrics(queries func() database.Querier, addr string) (func(), error) {
+	metrics.QueueLag.Set(func() (float64, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return queries().GetFeedQueueLag(ctx, time.Now().UTC())
+	})
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("Error listening for metrics: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go server.Serve(listener)
+	slog.Info("Serving metrics", "addr", listener.Addr().String())
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownGrace)
+		defer cancel()
+		server.Shutdown(ctx)
+	}, nil
+}
+
+// graceContext returns a context that is cancelled grace after parent is done
+func graceContext(parent context.Context, grace time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(parent))
+	go func() {
+		select {
+		case <-parent.Done():
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case <-time.After(grace):
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+func (t *aggTotals) add(stats *fetchStats, err error) {
+	if stats == nil {
+		return
+	}
+	t.fetches++
+	if err != nil {
+		t.failures++
+	}
+	t.newPosts += stats.newItems
+	t.updated += stats.updated
+}
+
+func (t *aggTotals) log() {
+	slog.Info("Stopping agg",
+		"uptime", time.Since(t.started).Round(time.Second),
+		"fetches", t.fetches,
+		"failed", t.failures,
+		"new_posts", t.newPosts,
+		"updated", t.updated)
+}
+
+func (t *aggTotals) print() {
+	fmt.Printf("%d fetches in %s, %d failed, %d new posts, %d updated\n",
+		t.fetches, time.Since(t.started).Round(time.Second), t.failures, t.newPosts, t.updated)
+}
+
+// reloadConfig rereads the config file, reconnecting if the database url changed
+func reloadConfig(s *State) error {
+	conf, err := config.Load()
+	if err != nil {
+		return err
+	}
+	err = logging.Setup(conf.LogLevel, conf.LogFormat, conf.LogFile)
+	if err != nil {
+		return err
+	}
+	if conf.DbUrl != s.Config.DbUrl {
+		conn, err := connect(conf.DbUrl)
+		if err != nil {
+			return err
+		}
+		old := s.Conn
+		s.Conn = conn
+		s.Db = database.New(metrics.DB(conn))
+		if old != nil {
+			old.Close()
+		}
+	}
+	*s.Config = conf
+	return nil
+}
+
+// connect opens the database at dbUrl and checks that it answers
+func connect(dbUrl string) (*sql.DB, error) {
+	conn, err := sql.Open("postgres", dbUrl)
+	if err != nil {
+		return nil, err
+	}
+	err = conn.Ping()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
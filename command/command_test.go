@@ -0,0 +1,342 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/config"
+	"github.com/luckyhut/gator/database"
+	"github.com/luckyhut/gator/memstore"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+<channel>
+	<title>Test Blog</title>
+	<link>https://blog.example.com/</link>
+	<description>Posts for testing</description>
+	<item>
+		<title>First post</title>
+		<link>https://blog.example.com/first</link>
+		<description>The first one</description>
+		<pubDate>Mon, 02 Jun 2025 10:00:00 +0000</pubDate>
+	</item>
+	<item>
+		<title>Second post</title>
+		<link>https://blog.example.com/second</link>
+		<description>The second one</description>
+		<pubDate>Tue, 03 Jun 2025 10:00:00 +0000</pubDate>
+	</item>
+</channel>
+</rss>`
+
+// newTestState returns a state backed by an in-memory store with the given users registered
+func newTestState(t *testing.T, users ...string) *State {
+	t.Helper()
+	s := &State{Config: &config.Config{}, Db: memstore.New()}
+	for _, name := range users {
+		_, err := s.Db.CreateUser(context.Background(), database.CreateUserParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			Name:      name,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+// run runs a command as user and returns what it printed
+func run(t *testing.T, s *State, user string, handler func(*State, Command) error, args ...string) (string, error) {
+	t.Helper()
+	s.Config.CurrentUserName = user
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	err = handler(s, Command{Args: args})
+	os.Stdout = stdout
+	w.Close()
+	out, readErr := io.ReadAll(r)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	return string(out), err
+}
+
+// serveFeed serves testFeed and counts how often it is fetched
+func serveFeed(t *testing.T) (string, *atomic.Int32) {
+	t.Helper()
+	fetches := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Header().Set("Content-Type", "application/rss+xml")
+		io.WriteString(w, testFeed)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/feed.xml", fetches
+}
+
+// fetchNext runs one round of a long running agg and returns what it fetched
+func fetchNext(s *State) aggTotals {
+	a := newAggregator(s, context.Background(), time.Hour)
+	a.fetchNext()
+	return a.totals
+}
+
+func TestFollowFetchAndBrowse(t *testing.T) {
+	ctx := context.Background()
+	s := newTestState(t, "alice", "bob")
+	url, _ := serveFeed(t)
+
+	_, err := run(t, s, "alice", MiddlewareLoggedIn(HandlerAddFeed), url)
+	if err != nil {
+		t.Fatalf("addfeed: %v", err)
+	}
+	_, err = run(t, s, "bob", MiddlewareLoggedIn(HandlerFollow), url)
+	if err != nil {
+		t.Fatalf("follow: %v", err)
+	}
+	_, err = run(t, s, "bob", MiddlewareLoggedIn(HandlerFollow), url)
+	if err == nil {
+		t.Error("following a feed twice succeeded")
+	}
+	_, err = run(t, s, "bob", MiddlewareLoggedIn(HandlerFollow), "https://unknown.example.com/feed.xml")
+	if err == nil {
+		t.Error("following a feed that was never added succeeded")
+	}
+
+	totals := fetchNext(s)
+	if totals.fetches != 1 || totals.failures != 0 || totals.newPosts != 2 {
+		t.Errorf("fetch: %+v, want one fetch with 2 new posts", totals)
+	}
+	feed, err := s.Db.GetFeedByUrl(ctx, sql.NullString{String: url, Valid: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Name.String != "Test Blog" || !feed.LastFetchedAt.Valid {
+		t.Errorf("feed after fetch: name %q, fetched %v", feed.Name.String, feed.LastFetchedAt.Valid)
+	}
+
+	out, err := run(t, s, "bob", HandlerBrowse, "10")
+	if err != nil {
+		t.Fatalf("browse: %v", err)
+	}
+	newer, older := strings.Index(out, "Second post"), strings.Index(out, "First post")
+	if newer < 0 || older < 0 || newer > older {
+		t.Errorf("browse should list both posts, newest first:\n%s", out)
+	}
+	if !strings.Contains(out, "Test Blog\n") || !strings.Contains(out, "https://blog.example.com/first\n") {
+		t.Errorf("browse output is missing the feed name or a post url:\n%s", out)
+	}
+
+	// a second fetch finds nothing new
+	totals = fetchNext(s)
+	if totals.fetches != 1 || totals.newPosts != 0 || totals.updated != 0 {
+		t.Errorf("second fetch: %+v, want one fetch with nothing new", totals)
+	}
+}
+
+func TestBrowseOnlyShowsFollowedFeeds(t *testing.T) {
+	s := newTestState(t, "alice", "bob")
+	url, _ := serveFeed(t)
+
+	_, err := run(t, s, "alice", MiddlewareLoggedIn(HandlerAddFeed), url)
+	if err != nil {
+		t.Fatalf("addfeed: %v", err)
+	}
+	if totals := fetchNext(s); totals.newPosts != 2 {
+		t.Fatalf("fetch: %+v, want 2 new posts", totals)
+	}
+
+	_, err = run(t, s, "bob", HandlerBrowse)
+	if err == nil {
+		t.Error("browse showed posts of a feed bob does not follow")
+	}
+	_, err = run(t, s, "bob", HandlerBrowse, "many")
+	if err == nil {
+		t.Error("browse accepted a post count that is not a number")
+	}
+}
+
+func TestFetchNextSkipsUnfollowedFeeds(t *testing.T) {
+	s := newTestState(t, "alice")
+	url, _ := serveFeed(t)
+
+	_, err := run(t, s, "alice", MiddlewareLoggedIn(HandlerAddFeed), url)
+	if err != nil {
+		t.Fatalf("addfeed: %v", err)
+	}
+	_, err = run(t, s, "alice", MiddlewareLoggedIn(HandlerUnfollow), url)
+	if err != nil {
+		t.Fatalf("unfollow: %v", err)
+	}
+	_, err = run(t, s, "alice", MiddlewareLoggedIn(HandlerUnfollow), url)
+	if err == nil {
+		t.Error("unfollowing a feed that is not followed succeeded")
+	}
+	_, err = run(t, s, "alice", MiddlewareLoggedIn(HandlerUnfollow))
+	if err == nil {
+		t.Error("unfollow without a url succeeded")
+	}
+
+	if totals := fetchNext(s); totals.fetches != 0 {
+		t.Errorf("agg fetched a feed nobody follows: %+v", totals)
+	}
+}
+
+func TestFeedRmKeepsStarredPosts(t *testing.T) {
+	ctx := context.Background()
+	s := newTestState(t, "alice", "bob")
+	url, _ := serveFeed(t)
+	_, err := run(t, s, "alice", MiddlewareLoggedIn(HandlerAddFeed), url)
+	if err != nil {
+		t.Fatalf("addfeed: %v", err)
+	}
+	if totals := fetchNext(s); totals.newPosts != 2 {
+		t.Fatalf("fetch: %+v, want 2 new posts", totals)
+	}
+	_, err = run(t, s, "bob", MiddlewareLoggedIn(HandlerStar), "https://blog.example.com/first")
+	if err != nil {
+		t.Fatalf("star: %v", err)
+	}
+
+	_, err = run(t, s, "bob", HandlerFeed, "rm", url)
+	if err == nil {
+		t.Error("a user who did not add the feed removed it")
+	}
+	out, err := run(t, s, "alice", HandlerFeed, "rm", url)
+	if err != nil {
+		t.Fatalf("feed rm: %v", err)
+	}
+	if !strings.Contains(out, "kept the feed") {
+		t.Errorf("feed rm should say the feed was kept:\n%s", out)
+	}
+
+	out, err = run(t, s, "bob", MiddlewareLoggedIn(HandlerStarred))
+	if err != nil {
+		t.Fatalf("starred: %v", err)
+	}
+	if !strings.Contains(out, "First post") {
+		t.Errorf("bob's starred post is gone:\n%s", out)
+	}
+	_, err = s.Db.GetPostByUrl(ctx, "https://blog.example.com/second")
+	if err == nil {
+		t.Error("a post nobody starred was kept")
+	}
+}
+
+func TestFindFeedRewritesPageUrls(t *testing.T) {
+	ctx := context.Background()
+	s := newTestState(t, "alice")
+	_, err := run(t, s, "alice", MiddlewareLoggedIn(HandlerAddFeed), "https://github.com/golang/go")
+	if err != nil {
+		t.Fatalf("addfeed: %v", err)
+	}
+	for _, url := range []string{"https://github.com/golang/go", "https://github.com/golang/go/releases.atom"} {
+		feed, err := findFeed(ctx, s, url)
+		if err != nil {
+			t.Errorf("findFeed(%q): %v", url, err)
+			continue
+		}
+		if feed.Url.String != "https://github.com/golang/go/releases.atom" {
+			t.Errorf("findFeed(%q) found %s", url, feed.Url.String)
+		}
+	}
+	_, err = run(t, s, "alice", MiddlewareLoggedIn(HandlerRule), "add", "https://github.com/golang/go", "drop", "title", "beta")
+	if err != nil {
+		t.Errorf("rule add with a page url: %v", err)
+	}
+	_, err = findFeed(ctx, s, "https://github.com/golang/tools")
+	if err == nil {
+		t.Error("findFeed found a feed that was never added")
+	}
+}
+
+func TestAggOnceFetchesDueFeeds(t *testing.T) {
+	ctx := context.Background()
+	s := newTestState(t, "alice")
+	url, fetches := serveFeed(t)
+	_, err := run(t, s, "alice", MiddlewareLoggedIn(HandlerAddFeed), url)
+	if err != nil {
+		t.Fatalf("addfeed: %v", err)
+	}
+	aggOnceHandler := func(all bool) func(*State, Command) error {
+		return func(s *State, cmd Command) error {
+			return aggOnce(ctx, ctx, s, all, cmd.Args)
+		}
+	}
+
+	out, err := run(t, s, "alice", aggOnceHandler(false))
+	if err != nil {
+		t.Fatalf("agg --once: %v", err)
+	}
+	if !strings.Contains(out, url+": 2 new") || fetches.Load() != 1 {
+		t.Errorf("agg --once should fetch the new feed:\n%s", out)
+	}
+	_, err = run(t, s, "alice", aggOnceHandler(false))
+	if err != nil || fetches.Load() != 1 {
+		t.Errorf("agg --once fetched a feed fetched just now: %v", err)
+	}
+	_, err = run(t, s, "alice", aggOnceHandler(true))
+	if err != nil || fetches.Load() != 2 {
+		t.Errorf("agg --once --all did not fetch every feed: %v", err)
+	}
+	_, err = run(t, s, "alice", aggOnceHandler(false), "soon")
+	if err == nil {
+		t.Error("agg --once accepted an age that is not a duration")
+	}
+
+	broken := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(broken.Close)
+	_, err = run(t, s, "alice", MiddlewareLoggedIn(HandlerAddFeed), broken.URL+"/feed.xml")
+	if err != nil {
+		t.Fatalf("addfeed: %v", err)
+	}
+	_, err = run(t, s, "alice", aggOnceHandler(false))
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != exitFeedsFailed {
+		t.Errorf("agg --once with a failing feed returned %v, want exit code %d", err, exitFeedsFailed)
+	}
+}
+
+func TestMarkAllReadBeforeUsesPublishedDates(t *testing.T) {
+	s := newTestState(t, "alice")
+	url, _ := serveFeed(t)
+	_, err := run(t, s, "alice", MiddlewareLoggedIn(HandlerAddFeed), url)
+	if err != nil {
+		t.Fatalf("addfeed: %v", err)
+	}
+	if totals := fetchNext(s); totals.newPosts != 2 {
+		t.Fatalf("fetch: %+v, want 2 new posts", totals)
+	}
+
+	// both posts were stored just now, but only the first was published before the 3rd
+	out, err := run(t, s, "alice", MiddlewareLoggedIn(HandlerMarkAllRead), "--before", "2025-06-03T00:00:00Z")
+	if err != nil {
+		t.Fatalf("mark-all-read --before: %v", err)
+	}
+	if !strings.Contains(out, "Marked 1 posts read") {
+		t.Errorf("mark-all-read --before should mark only the first post:\n%s", out)
+	}
+	out, err = run(t, s, "alice", MiddlewareLoggedIn(HandlerMarkAllRead))
+	if err != nil {
+		t.Fatalf("mark-all-read: %v", err)
+	}
+	if !strings.Contains(out, "Marked 1 posts read") {
+		t.Errorf("mark-all-read should mark the post left unread:\n%s", out)
+	}
+}
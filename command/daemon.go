@@ -0,0 +1,360 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/luckyhut/gator/control"
+	"github.com/luckyhut/gator/database"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// addfeed and follow notify this Postgres channel with the feed's url
+const feedsChannel = "gator_feeds"
+
+// how long gator ctl waits for the daemon; a refresh has to wait for any fetch already running
+const ctlTimeout = 2*fetchTimeout + 30*time.Second
+
+// aggregator is the state of a long running agg, shared with its control socket
+type aggregator struct {
+	s        *State
+	fetchCtx context.Context
+	interval time.Duration
+
+	// guards s.Db, which a reload swaps while status and metrics read it
+	dbMu sync.RWMutex
+
+	mu       sync.Mutex
+	paused   bool
+	inFlight map[string]time.Time
+	totals   aggTotals
+
+	// wakes the fetch loop when a feed is added or followed
+	listener *pq.Listener
+
+	// requests the control socket hands to the fetch loop
+	refresh chan ctlRequest
+	reload  chan ctlRequest
+}
+
+type ctlRequest struct {
+	args  []string
+	reply chan control.Response
+}
+
+func newAggregator(s *State, fetchCtx context.Context, interval time.Duration) *aggregator {
+	return &aggregator{
+		s:        s,
+		fetchCtx: fetchCtx,
+		interval: interval,
+		inFlight: make(map[string]time.Time),
+		totals:   aggTotals{started: time.Now()},
+		refresh:  make(chan ctlRequest),
+		reload:   make(chan ctlRequest),
+	}
+}
+
+// queries returns the current database queries for use off the fetch loop
+func (a *aggregator) queries() database.Querier {
+	a.dbMu.RLock()
+	defer a.dbMu.RUnlock()
+	return a.s.Db
+}
+
+// run fetches one feed every interval until ctx is done
+func (a *aggregator) run(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	err := a.listen()
+	if err != nil {
+		return err
+	}
+	defer func() { a.listener.Close() }()
+
+	server, err := control.Listen(a.s.Config.SocketPath(), a.handle)
+	if err != nil {
+		return err
+	}
+	defer server.Close()
+
+	slog.Info("Collecting feeds", "every", a.interval, "control_socket", a.s.Config.SocketPath())
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	pruneTicker := time.NewTicker(pruneInterval)
+	defer pruneTicker.Stop()
+
+	for {
+		if !a.isPaused() {
+			a.fetchNext()
+		}
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				a.mu.Lock()
+				a.totals.log()
+				a.mu.Unlock()
+				return nil
+			case <-hup:
+				a.reloadConfig()
+			case req := <-a.reload:
+				req.reply <- a.reloadConfig()
+			case n := <-a.listener.Notify:
+				// nil after the listener reconnects, when notifications may have been missed
+				if n != nil {
+					a.fetchAdded(n.Extra)
+				}
+			case req := <-a.refresh:
+				req.reply <- a.refreshFeed(req.args)
+			case <-pruneTicker.C:
+				a.prune()
+			case <-ticker.C:
+				break wait
+			}
+		}
+	}
+}
+
+// listen starts listening on feedsChannel on the configured database
+func (a *aggregator) listen() error {
+	listener := pq.NewListener(a.s.Config.DbUrl, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Warn("Feed notification listener", "err", err)
+		}
+	})
+	err := listener.Listen(feedsChannel)
+	if err != nil {
+		listener.Close()
+		return fmt.Errorf("Error listening for new feeds: %w", err)
+	}
+	a.listener = listener
+	return nil
+}
+
+// fetchAdded fetches a feed that was just added or followed, unless it was fetched within the last interval
+func (a *aggregator) fetchAdded(url string) {
+	if a.isPaused() {
+		return
+	}
+	feed, err := a.s.Db.GetFeedByUrl(a.fetchCtx, sql.NullString{String: url, Valid: true})
+	if err != nil {
+		slog.Error("Error getting new feed from database", "url", url, "err", err)
+		return
+	}
+	// last_fetched_at comes from Go's clock too, see fetchedNow
+	if feed.LastFetchedAt.Valid && time.Since(feed.LastFetchedAt.Time) < a.interval {
+		return
+	}
+	feed, err = claimFeed(a.fetchCtx, a.s, feed.ID)
+	if errors.Is(err, errFeedLeased) {
+		// another worker got the notification first
+		return
+	}
+	if err != nil {
+		slog.Error("Error claiming new feed", "url", url, "err", err)
+		return
+	}
+	slog.Info("Fetching new feed", "url", url)
+	a.fetch(&feed)
+}
+
+// prune applies the retention limits
+func (a *aggregator) prune() {
+	result, err := prunePosts(a.fetchCtx, a.s, false)
+	if err != nil {
+		slog.Error("Error pruning posts", "err", err)
+		return
+	}
+	if result.total > 0 {
+		slog.Info("Pruned posts", "posts", result.total, "feeds", len(result.feeds))
+	}
+
+	orphans, err := removeOrphans(a.fetchCtx, a.s, false)
+	if err != nil {
+		slog.Error("Error removing orphaned feeds", "err", err)
+		return
+	}
+	for _, url := range orphans.feeds {
+		slog.Info("Removed orphaned feed", "url", url)
+	}
+	if orphans.posts > 0 {
+		slog.Info("Deleted posts of orphaned feeds", "posts", orphans.posts, "feeds", len(orphans.feeds)+len(orphans.kept))
+	}
+}
+
+func (a *aggregator) isPaused() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.paused
+}
+
+func (a *aggregator) fetchNext() {
+	nextFeed, err := a.s.Db.ClaimNextFeed(a.fetchCtx, int32(feedLease.Seconds()))
+	if errors.Is(err, sql.ErrNoRows) {
+		slog.Warn("No feeds to fetch, every followed feed is leased by a worker")
+		return
+	}
+	if err != nil {
+		slog.Error("Error getting next feed from database", "err", err)
+		return
+	}
+	a.fetch(&nextFeed)
+}
+
+// fetch runs fetchAndStore on a claimed feed, tracking it as in flight
+func (a *aggregator) fetch(feed *database.Feed) (*fetchStats, error) {
+	a.mu.Lock()
+	a.inFlight[feed.Url.String] = time.Now()
+	a.mu.Unlock()
+
+	stats, err := fetchAndStore(a.fetchCtx, a.s, feed)
+
+	a.mu.Lock()
+	delete(a.inFlight, feed.Url.String)
+	a.totals.add(stats, err)
+	a.mu.Unlock()
+	return stats, err
+}
+
+func (a *aggregator) reloadConfig() control.Response {
+	dbUrl := a.s.Config.DbUrl
+	a.dbMu.Lock()
+	err := reloadConfig(a.s)
+	a.dbMu.Unlock()
+	if err == nil && a.s.Config.DbUrl != dbUrl {
+		old := a.listener
+		err = a.listen()
+		if err == nil {
+			old.Close()
+		}
+	}
+	if err != nil {
+		slog.Error("Error reloading config", "err", err)
+		return control.Response{Error: fmt.Sprintf("Error reloading config: %v", err)}
+	}
+	slog.Info("Config reloaded")
+	return control.Response{Output: "Config reloaded"}
+}
+
+func (a *aggregator) refreshFeed(args []string) control.Response {
+	if len(args) == 0 {
+		return control.Response{Error: "Must include a feed url with this command"}
+	}
+	feed, err := a.s.Db.GetFeedByUrl(a.fetchCtx, sql.NullString{String: args[0], Valid: true})
+	if err != nil {
+		return control.Response{Error: "Error getting feed from database"}
+	}
+	feed, err = claimFeed(a.fetchCtx, a.s, feed.ID)
+	if err != nil {
+		return control.Response{Error: err.Error()}
+	}
+	stats, err := a.fetch(&feed)
+	if err != nil {
+		return control.Response{Error: err.Error()}
+	}
+	return control.Response{Output: fmt.Sprintf("%s: %d new, %d updated, %d skipped",
+		feedName(&feed), stats.newItems, stats.updated, stats.skipped)}
+}
+
+// handle answers a request from gator ctl. It runs on the control socket's goroutines,
+// so anything that fetches or touches the config is passed to the fetch loop.
+func (a *aggregator) handle(req control.Request) control.Response {
+	switch req.Command {
+	case "status":
+		return a.status()
+	case "pause", "resume":
+		a.mu.Lock()
+		a.paused = req.Command == "pause"
+		a.mu.Unlock()
+		slog.Info("Fetching " + req.Command + "d")
+		return control.Response{Output: "Fetching " + req.Command + "d"}
+	case "refresh":
+		return a.toLoop(a.refresh, req.Args)
+	case "reload":
+		return a.toLoop(a.reload, req.Args)
+	}
+	return control.Response{Error: fmt.Sprintf("Unknown ctl command %s", req.Command)}
+}
+
+func (a *aggregator) toLoop(ch chan ctlRequest, args []string) control.Response {
+	req := ctlRequest{args: args, reply: make(chan control.Response, 1)}
+	select {
+	case ch <- req:
+	case <-a.fetchCtx.Done():
+		return control.Response{Error: "agg is shutting down"}
+	}
+	return <-req.reply
+}
+
+func (a *aggregator) status() control.Response {
+	var out strings.Builder
+
+	a.mu.Lock()
+	state := "running"
+	if a.paused {
+		state = "paused"
+	}
+	fmt.Fprintf(&out, "state:     %s, fetching every %s\n", state, a.interval)
+	fmt.Fprintf(&out, "uptime:    %s (since %s)\n", time.Since(a.totals.started).Round(time.Second), a.totals.started.Format(time.DateTime))
+	fmt.Fprintf(&out, "fetches:   %d, %d failed, %d new posts, %d updated\n",
+		a.totals.fetches, a.totals.failures, a.totals.newPosts, a.totals.updated)
+	var urls []string
+	for url := range a.inFlight {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	inFlight := make([]string, 0, len(urls))
+	for _, url := range urls {
+		inFlight = append(inFlight, fmt.Sprintf("  %s (%s)", url, time.Since(a.inFlight[url]).Round(time.Second)))
+	}
+	a.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	db := a.queries()
+	count, err := db.CountFeeds(ctx)
+	if err == nil {
+		lag, err := db.GetFeedQueueLag(ctx, time.Now().UTC())
+		if err == nil {
+			fmt.Fprintf(&out, "queue:     %d feeds, longest wait %s\n", count, (time.Duration(lag) * time.Second).Round(time.Second))
+		}
+	}
+
+	fmt.Fprintf(&out, "in flight: %d\n", len(inFlight))
+	for _, line := range inFlight {
+		fmt.Fprintln(&out, line)
+	}
+	return control.Response{Output: strings.TrimRight(out.String(), "\n")}
+}
+
+// HandlerCtl sends a command to the agg running on this machine
+func HandlerCtl(s *State, cmd Command) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Must include a subcommand: status, refresh <url>, pause, resume or reload")
+	}
+	req := control.Request{Command: cmd.Args[0], Args: cmd.Args[1:]}
+	if req.Command == "refresh" && len(req.Args) > 0 {
+		req.Args[0] = rewriteFeedUrl(req.Args[0])
+	}
+	resp, err := control.Call(s.Config.SocketPath(), req, ctlTimeout)
+	if err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	fmt.Println(resp.Output)
+	return nil
+}
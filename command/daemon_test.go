@@ -0,0 +1,45 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"github.com/luckyhut/gator/database"
+	"testing"
+	"time"
+)
+
+func TestFetchAddedSkipsRecentlyFetchedFeeds(t *testing.T) {
+	ctx := context.Background()
+	s := newTestState(t, "alice")
+	url, fetches := serveFeed(t)
+	_, err := run(t, s, "alice", MiddlewareLoggedIn(HandlerAddFeed), url)
+	if err != nil {
+		t.Fatalf("addfeed: %v", err)
+	}
+	a := newAggregator(s, ctx, time.Hour)
+
+	a.fetchAdded(url)
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("a new feed was fetched %d times, want 1", n)
+	}
+	a.fetchAdded(url)
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("a feed fetched just now was fetched again")
+	}
+
+	feed, err := s.Db.GetFeedByUrl(ctx, sql.NullString{String: url, Valid: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Db.MarkFeedFetched(ctx, database.MarkFeedFetchedParams{
+		FetchedAt: time.Now().UTC().Add(-2 * time.Hour),
+		ID:        feed.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.fetchAdded(url)
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("a feed last fetched two intervals ago was not fetched again")
+	}
+}
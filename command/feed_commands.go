@@ -3,73 +3,212 @@ package command
 import (
 	"context"
 	"database/sql"
-	"encoding/xml"
 	"errors"
+	"flag"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/luckyhut/gator/database"
+	"github.com/luckyhut/gator/feedurl"
+	"github.com/luckyhut/gator/metrics"
 	"github.com/luckyhut/gator/rss"
 	"html"
 	"io"
+	"log/slog"
 	"net/http"
-	"reflect"
 	"strconv"
 	"strings"
 	"time"
 )
 
-func HandlerAgg(s *State, cmd Command) error {
-	if len(cmd.Args) < 1 {
-		return errors.New("Not enough arguments")
+// how long a claimed feed stays leased to this worker. A worker that dies
+// mid-fetch loses its lease after this and another worker takes the feed.
+const feedLease = 5 * time.Minute
+
+// fetches taking longer than this are cancelled so they finish well inside the lease
+const fetchTimeout = 2 * time.Minute
+
+// errFeedLeased is returned when another worker is already fetching a feed
+var errFeedLeased = errors.New("Feed is being fetched by another worker")
+
+// observeFetch records a finished fetch in the Prometheus metrics
+func observeFetch(feed *database.Feed, stats *fetchStats, err error) {
+	result := "ok"
+	if err != nil {
+		result = "failed"
+	}
+	code := "none"
+	if stats.status != 0 {
+		code = strconv.Itoa(stats.status)
+	}
+	metrics.FeedFetches.Inc(result, code)
+	metrics.FetchDuration.Observe(time.Since(stats.startedAt).Seconds(), feed.Kind)
+	metrics.PostsIngested.Add(float64(stats.newItems), "new")
+	metrics.PostsIngested.Add(float64(stats.updated), "updated")
+	metrics.PostsIngested.Add(float64(stats.skipped), "skipped")
+}
+
+func logFetch(feed *database.Feed, stats *fetchStats, err error) {
+	attrs := []any{
+		"feed_id", feed.ID,
+		"url", feed.Url.String,
+		"duration", time.Since(stats.startedAt),
+		"status", stats.status,
+		"bytes", stats.bytes,
+		"new", stats.newItems,
+		"updated", stats.updated,
+		"skipped", stats.skipped,
 	}
-	duration, err := time.ParseDuration(cmd.Args[0])
 	if err != nil {
-		return errors.New("Error parsing duration")
+		slog.Warn("Feed fetch failed", append(attrs, "err", err)...)
+		return
 	}
+	slog.Info("Fetched feed", attrs...)
+}
 
-	fmt.Println("Collecting feeds every", duration)
-	ticker := time.NewTicker(duration)
-	defer ticker.Stop()
+// fetchedNow marks a feed fetched as of now. The time comes from Go like every other
+// stored time, because NOW() in a TIMESTAMP column would be in the server's time zone
+// and fetch schedules compare last_fetched_at with times from Go.
+func fetchedNow(feedID uuid.UUID) database.MarkFeedFetchedParams {
+	return database.MarkFeedFetchedParams{FetchedAt: time.Now().UTC(), ID: feedID}
+}
 
-	for ; ; <-ticker.C {
-		err := scrapeFeeds(s)
-		if err != nil {
-			return err
-		}
+// claimFeed leases a specific feed to this worker
+func claimFeed(ctx context.Context, s *State, feedID uuid.UUID) (database.Feed, error) {
+	feed, err := s.Db.ClaimFeed(ctx, database.ClaimFeedParams{
+		LeaseSeconds: int32(feedLease.Seconds()),
+		ID:           feedID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return feed, errFeedLeased
+	}
+	if err != nil {
+		return feed, errors.New("Error claiming feed")
 	}
+	return feed, nil
 }
 
-func scrapeFeeds(s *State) error {
-	ctx := context.Background()
-	nextFeed, err := s.Db.GetNextFeedToFetch(ctx)
+// fetchAndStore fetches a feed this worker has claimed, stores its posts,
+// records the attempt in the feed's history and releases the lease
+func fetchAndStore(ctx context.Context, s *State, nextFeed *database.Feed) (*fetchStats, error) {
+	stats := &fetchStats{startedAt: time.Now().UTC()}
+	fetchCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	feed, err := fetchSource(fetchCtx, s, nextFeed, stats)
+	fetched := err == nil
+	if fetched {
+		err = storeFeed(fetchCtx, s, nextFeed, feed, stats)
+	}
+	cancel()
+
+	// a fetch cut short by shutdown is still worth recording
+	ctx = context.WithoutCancel(ctx)
+	recordErr := recordFetch(ctx, s, nextFeed.ID, stats, err)
+
+	var leaseErr error
+	switch {
+	case !fetched:
+		// failed feeds are marked too, so a broken feed doesn't stay at the front of the queue
+		_, leaseErr = s.Db.MarkFeedFetched(ctx, fetchedNow(nextFeed.ID))
+	case err != nil:
+		// nothing was stored, so the feed is released without being marked and fetched again next round
+		leaseErr = s.Db.ReleaseFeed(ctx, nextFeed.ID)
+	}
+	observeFetch(nextFeed, stats, err)
+	logFetch(nextFeed, stats, err)
 	if err != nil {
-		return errors.New("Error getting next feed from database")
+		return stats, err
 	}
+	if recordErr != nil {
+		return stats, recordErr
+	}
+	if leaseErr != nil {
+		return stats, errors.New("Error releasing feed")
+	}
+	return stats, nil
+}
 
-	s.Db.MarkFeedFetched(ctx, nextFeed.ID)
-
-	feed, err := fetchFeed(ctx, nextFeed.Url.String)
+// storeFeed saves a fetched feed's metadata and posts and marks it fetched in one
+// transaction, so a failure part way leaves the feed as it was before the fetch
+func storeFeed(ctx context.Context, s *State, nextFeed *database.Feed, feed *rss.RSSFeed, stats *fetchStats) error {
+	pipeline, _, err := feedPipeline(ctx, s, nextFeed.ID)
 	if err != nil {
 		return err
 	}
 
-	for _, item := range feed.Channel.Item {
-		params := createPostParams(&item, &nextFeed)
-		err = s.Db.CreatePost(ctx, *params)
+	var newItems, updated, skipped int
+	err = inTx(ctx, s, func(q database.Querier) error {
+		err := q.UpdateFeedMetadata(ctx, channelMetadata(feed, nextFeed.ID))
 		if err != nil {
-			if strings.Contains(err.Error(), "duplicate key") {
+			return errors.New("Error updating feed metadata")
+		}
+
+		for _, item := range feed.Channel.Item {
+			if pipeline.Apply(&item) >= 0 {
+				skipped++
 				continue
 			}
-			fmt.Println("Error type: ", reflect.TypeOf(err))
-			return errors.New("Error adding post to database")
+			params := createPostParams(&item, nextFeed)
+			post, err := q.UpsertPost(ctx, *params)
+			if errors.Is(err, sql.ErrNoRows) {
+				// already stored and unchanged, or the url belongs to another feed
+				skipped++
+				continue
+			}
+			if err != nil {
+				slog.Error("Error adding post to database", "feed_id", nextFeed.ID, "post_url", params.Url, "err", err)
+				return errors.New("Error adding post to database")
+			}
+			err = storePostMetadata(ctx, q, post.ID, &item)
+			if err != nil {
+				return err
+			}
+			if post.Inserted {
+				newItems++
+			} else {
+				updated++
+			}
 		}
+
+		_, err = q.MarkFeedFetched(ctx, fetchedNow(nextFeed.ID))
+		if err != nil {
+			return errors.New("Error marking feed fetched")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	stats.newItems, stats.updated, stats.skipped = newItems, updated, skipped
 	return nil
 }
 
-func fetchFeed(ctx context.Context, feedURL string) (*rss.RSSFeed, error) {
+func fetchFeed(ctx context.Context, feedURL string, stats *fetchStats) (*rss.RSSFeed, error) {
+	body, err := fetchBody(ctx, feedURL, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseFeed(body)
+}
+
+// parseFeed detects the format of a feed document and decodes it
+func parseFeed(body []byte) (*rss.RSSFeed, error) {
+	feed, format, err := rss.Parse(body)
+	if err != nil {
+		if format == "" {
+			format = "unknown"
+		}
+		metrics.ParseErrors.Inc(format)
+		return nil, err
+	}
+
+	unescapeHtml(feed)
+
+	return feed, nil
+}
+
+func fetchBody(ctx context.Context, pageURL string, stats *fetchStats) ([]byte, error) {
 	// make an http request and client
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
 	if err != nil {
 		return nil, errors.New("Unable to get a request")
 	}
@@ -82,24 +221,68 @@ func fetchFeed(ctx context.Context, feedURL string) (*rss.RSSFeed, error) {
 		return nil, errors.New("Error running HTTP request")
 	}
 	defer resp.Body.Close()
+	stats.status = resp.StatusCode
 
-	// use xml.Unmarshal to fit the response in a struct
 	body, err := io.ReadAll(resp.Body)
+	stats.bytes = len(body)
 	if err != nil {
 		return nil, errors.New("Error reading response body")
 	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("Feed returned HTTP status %d", resp.StatusCode)
+	}
+	return body, nil
+}
 
-	// xml.Unmarshal
-	var feed rss.RSSFeed
-	err = xml.Unmarshal(body, &feed)
-	if err != nil {
-		fmt.Println(feed)
-		return nil, errors.New("Error unmarshaling xml data")
+// rewriteFeedUrl swaps known page urls (YouTube channels, subreddits, ...)
+// for their feed url, and tells the user when it does
+func rewriteFeedUrl(raw string) string {
+	feedUrl, name, ok := feedurl.Rewrite(raw)
+	if ok {
+		fmt.Printf("Using %s feed url %s\n", name, feedUrl)
 	}
+	return feedUrl
+}
 
-	unescapeHtml(&feed)
+// findFeed looks up the feed named by a url given on the command line. Like addfeed it
+// swaps a page url for its feed url, but a feed stored under the page url itself, the
+// way scrape add stores pages, is found as well.
+func findFeed(ctx context.Context, s *State, raw string) (database.Feed, error) {
+	urls := []string{raw}
+	feedUrl, name, rewritten := feedurl.Rewrite(raw)
+	if rewritten {
+		urls = []string{feedUrl, raw}
+	}
+	for i, url := range urls {
+		feed, err := s.Db.GetFeedByUrl(ctx, sql.NullString{String: url, Valid: true})
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return feed, errors.New("Error getting feed from database")
+		}
+		if rewritten && i == 0 {
+			fmt.Printf("Using %s feed url %s\n", name, feedUrl)
+		}
+		return feed, nil
+	}
+	return database.Feed{}, fmt.Errorf("No feed with url %s", urls[0])
+}
 
-	return &feed, nil
+func channelMetadata(feed *rss.RSSFeed, feedID uuid.UUID) database.UpdateFeedMetadataParams {
+	text := func(s string) sql.NullString {
+		s = strings.TrimSpace(s)
+		return sql.NullString{String: s, Valid: s != ""}
+	}
+	return database.UpdateFeedMetadataParams{
+		ID:          feedID,
+		Title:       text(feed.Channel.Title),
+		Description: text(feed.Channel.Description),
+		Link:        text(feed.SiteLink()),
+		ImageUrl:    text(feed.Channel.Image.URL),
+		Language:    text(feed.Channel.Language),
+		Generator:   text(feed.Channel.Generator),
+	}
 }
 
 func unescapeHtml(feed *rss.RSSFeed) {
@@ -111,8 +294,8 @@ func unescapeHtml(feed *rss.RSSFeed) {
 	}
 }
 
-func createPostParams(item *rss.RSSItem, feed *database.Feed) *database.CreatePostParams {
-	params := database.CreatePostParams{
+func createPostParams(item *rss.RSSItem, feed *database.Feed) *database.UpsertPostParams {
+	params := database.UpsertPostParams{
 		ID:          uuid.New(),
 		CreatedAt:   time.Now().UTC(),
 		UpdatedAt:   time.Now().UTC(),
@@ -121,42 +304,104 @@ func createPostParams(item *rss.RSSItem, feed *database.Feed) *database.CreatePo
 		Description: sql.NullString{String: item.Description, Valid: true},
 		PublishedAt: sql.NullString{String: item.PubDate, Valid: true},
 		FeedID:      feed.ID,
+		Guid:        sql.NullString{String: item.GUID, Valid: item.GUID != ""},
 	}
 	return &params
 }
 
 func HandlerAddFeed(s *State, cmd Command, user database.User) error {
-	if len(cmd.Args) < 2 {
-		return errors.New("Must include a name and url with this command")
+	if len(cmd.Args) == 0 {
+		return errors.New("Must include a url, and optionally a name, with this command")
 	}
 
-	params := database.CreateFeedParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		Name:      sql.NullString{String: cmd.Args[0], Valid: true},
-		Url:       sql.NullString{String: cmd.Args[1], Valid: true},
-		UserID:    uuid.NullUUID{UUID: user.ID, Valid: true},
+	// with only a url the name is filled in from the channel title on the first fetch
+	name := sql.NullString{}
+	feedUrl := cmd.Args[0]
+	if len(cmd.Args) > 1 {
+		name = sql.NullString{String: cmd.Args[0], Valid: true}
+		feedUrl = cmd.Args[1]
+	}
+	feedUrl = rewriteFeedUrl(feedUrl)
+	switch sourceKind(feedUrl) {
+	case kindMailbox:
+		_, err := mailboxPath(s.Config, feedUrl)
+		if err != nil {
+			return err
+		}
+	case kindExec:
+		_, err := execArgs(s.Config, feedUrl)
+		if err != nil {
+			return err
+		}
 	}
 
-	s.Db.CreateFeed(context.Background(), params)
+	// the feed and its follow are created together, so a failed follow leaves no feed behind
+	ctx := context.Background()
+	err := inTx(ctx, s, func(q database.Querier) error {
+		_, err := q.GetFeed(ctx, sql.NullString{String: feedUrl, Valid: true})
+		if err == nil {
+			return fmt.Errorf("Feed %s has already been added, use gator follow to follow it", feedUrl)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return errors.New("Error getting feed from database")
+		}
 
-	feed_id, err := s.Db.GetFeed(context.Background(), sql.NullString{String: cmd.Args[1], Valid: true})
+		feed, err := q.CreateFeed(ctx, database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			Name:      name,
+			Url:       sql.NullString{String: feedUrl, Valid: true},
+			UserID:    uuid.NullUUID{UUID: user.ID, Valid: true},
+			Kind:      sourceKind(feedUrl),
+		})
+		if err != nil {
+			return errors.New("Could not create feed")
+		}
+
+		err = q.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			UserID:    user.ID,
+			FeedID:    feed.ID,
+		})
+		if err != nil {
+			return errors.New("Could not create FeedFollow record")
+		}
+		return nil
+	})
 	if err != nil {
-		return errors.New("Error getting feed from database")
+		return err
 	}
+	notifyFeedAdded(s, feedUrl)
 
-	feedFollowParams := database.CreateFeedFollowParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		UserID:    user.ID,
-		FeedID:    feed_id,
-	}
+	return nil
+}
 
-	s.Db.CreateFeedFollow(context.Background(), feedFollowParams)
+// subcommands of gator feed
+var feedCommands = map[string]func(*State, Command) error{
+	"history":   handlerFeedHistory,
+	"retention": handlerFeedRetention,
+	"rm":        handlerFeedRm,
+}
 
-	return nil
+// mayManageFeed reports whether user may change settings that apply to everyone
+// following a feed: the user who added it and admins may
+func mayManageFeed(s *State, user database.User, feed *database.Feed) bool {
+	owner := feed.UserID.Valid && feed.UserID.UUID == user.ID
+	return owner || s.Config.IsAdmin(user.Name)
+}
+
+func HandlerFeed(s *State, cmd Command) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Must include a subcommand with this command")
+	}
+	f, exists := feedCommands[cmd.Args[0]]
+	if !exists {
+		return fmt.Errorf("Unknown feed subcommand %s", cmd.Args[0])
+	}
+	return f(s, Command{Name: cmd.Args[0], Args: cmd.Args[1:]})
 }
 
 func HandlerFeeds(s *State, cmd Command) error {
@@ -165,18 +410,42 @@ func HandlerFeeds(s *State, cmd Command) error {
 	if err != nil {
 		return errors.New("Unable to get list of feeds from database")
 	}
-	fmt.Println("handlerFeeds result: ", result)
+
+	for _, feed := range result {
+		name := feed.Name.String
+		if name == "" {
+			name = "(not fetched yet)"
+		}
+		fmt.Printf("* %s\n", name)
+		fmt.Printf("  feed:  %s\n", feed.Url.String)
+		if feed.Link.Valid && feed.Link.String != "" {
+			fmt.Printf("  site:  %s\n", feed.Link.String)
+		}
+		if feed.Description.Valid && feed.Description.String != "" {
+			fmt.Printf("  about: %s\n", feed.Description.String)
+		}
+		fmt.Printf("  added by %s\n", feed.Name_2)
+	}
 
 	return nil
 }
 
 func HandlerBrowse(s *State, cmd Command) error {
+	flags := flag.NewFlagSet("browse", flag.ContinueOnError)
+	category := flags.String("category", "", "only show posts in this category")
+	author := flags.String("author", "", "only show posts by this author")
+	unread := flags.Bool("unread", false, "only show posts you haven't read")
+	tag := flags.String("tag", "", "only show posts from feeds you tagged with this")
+	err := flags.Parse(cmd.Args)
+	if err != nil {
+		return err
+	}
+
 	numPosts := 0
-	var err error
-	if len(cmd.Args) == 0 {
+	if flags.NArg() == 0 {
 		numPosts = 2
 	} else {
-		numPosts, err = strconv.Atoi(cmd.Args[0])
+		numPosts, err = strconv.Atoi(flags.Arg(0))
 		if err != nil {
 			return errors.New("Number of posts must be an integer")
 		}
@@ -186,23 +455,24 @@ func HandlerBrowse(s *State, cmd Command) error {
 	if err != nil {
 		return err
 	}
-	posts, err := s.Db.GetPostsForUser(ctx, userUuid.ID)
+	params := database.GetPostsForUserParams{
+		UserID:     userUuid.ID,
+		Category:   sql.NullString{String: normalizeCategory(*category), Valid: *category != ""},
+		Author:     sql.NullString{String: normalizeAuthor(*author), Valid: *author != ""},
+		UnreadOnly: *unread,
+		Tag:        sql.NullString{String: normalizeTag(*tag), Valid: *tag != ""},
+	}
+	posts, err := s.Db.GetPostsForUser(ctx, params)
 	if err != nil {
 		return err
 	}
 	if len(posts) == 0 {
 		return errors.New("No posts to display")
 	}
-	if len(posts) == 1 {
-		fmt.Println("---------------------------------------------------")
-		fmt.Printf("%s\n", posts[0].Title.String)
-		fmt.Printf("%s\n", posts[0].Description.String)
-		fmt.Printf("%s\n", posts[0].Url)
-		fmt.Println("---------------------------------------------------")
-	}
-	for i := 0; i < numPosts; i++ {
+	for i := 0; i < numPosts && i < len(posts); i++ {
 		fmt.Println("---------------------------------------------------")
-		fmt.Printf("%s\n", posts[i].Title.String)
+		fmt.Printf("[%s] %s\n", shortID(posts[i].ID), posts[i].Title.String)
+		fmt.Printf("%s\n", posts[i].FeedName.String)
 		fmt.Printf("%s\n", posts[i].Description.String)
 		fmt.Printf("%s\n", posts[i].Url)
 		fmt.Println("---------------------------------------------------")
@@ -223,10 +493,9 @@ func HandlerFollow(s *State, cmd Command, user database.User) error {
 	curTime := time.Now().UTC()
 
 	// feed_id
-	url := sql.NullString{String: cmd.Args[0], Valid: true}
-	feed_id, err := s.Db.GetFeed(dbContext, url) // feed_id
+	feed, err := findFeed(dbContext, s, cmd.Args[0])
 	if err != nil {
-		return errors.New("Error getting feed from database")
+		return err
 	}
 
 	params := database.CreateFeedFollowParams{
@@ -234,31 +503,48 @@ func HandlerFollow(s *State, cmd Command, user database.User) error {
 		CreatedAt: curTime,
 		UpdatedAt: curTime,
 		UserID:    user.ID,
-		FeedID:    feed_id,
+		FeedID:    feed.ID,
 	}
 
 	err = s.Db.CreateFeedFollow(dbContext, params)
 	if err != nil {
 		return errors.New("Could not create FeedFollow record")
 	}
+	notifyFeedAdded(s, feed.Url.String)
 
 	return nil
 }
 
+// notifyFeedAdded wakes any running agg so it fetches the feed now instead of on its next round
+func notifyFeedAdded(s *State, feedUrl string) {
+	err := s.Db.NotifyFeedAdded(context.Background(), feedUrl)
+	if err != nil {
+		slog.Warn("Could not notify agg of new feed", "url", feedUrl, "err", err)
+	}
+}
+
 func HandlerUnfollow(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Must include a feed url with this command")
+	}
 	dbContext := context.Background()
-	url := sql.NullString{String: cmd.Args[0], Valid: true}
-	feed_id, err := s.Db.GetFeed(dbContext, url) // feed_id
+	feed, err := findFeed(dbContext, s, cmd.Args[0])
 	if err != nil {
-		return errors.New("Error getting feed from database")
+		return err
 	}
 
 	params := database.UnfollowParams{
 		UserID: user.ID,
-		FeedID: feed_id,
+		FeedID: feed.ID,
 	}
 
-	s.Db.Unfollow(dbContext, params)
+	removed, err := s.Db.Unfollow(dbContext, params)
+	if err != nil {
+		return errors.New("Could not remove FeedFollow record")
+	}
+	if len(removed) == 0 {
+		return fmt.Errorf("Not following %s", feed.Url.String)
+	}
 	return nil
 }
 
@@ -276,8 +562,22 @@ func HandlerFollowing(s *State, cmd Command, user database.User) error {
 		return nil
 	}
 
+	// follows come sorted by tag, once per tag, with untagged ones last.
+	// Headings are only worth printing once the user has tagged something.
+	grouped := result[0].Tag.Valid
 	for i := 0; i < len(result); i++ {
-		fmt.Print("* ", result[i].FeedName.String, "\n")
+		if grouped && (i == 0 || result[i].Tag != result[i-1].Tag) {
+			if !result[i].Tag.Valid {
+				fmt.Println("untagged:")
+			} else {
+				fmt.Printf("%s:\n", result[i].Tag.String)
+			}
+		}
+		indent := ""
+		if grouped {
+			indent = "  "
+		}
+		fmt.Printf("%s* %s (%d unread)\n", indent, result[i].FeedName.String, result[i].UnreadCount)
 	}
 
 	return nil
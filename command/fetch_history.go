@@ -0,0 +1,105 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/database"
+	"strconv"
+	"time"
+)
+
+// number of fetch attempts kept per feed, older rows are pruned after each fetch
+const fetchHistoryLimit = 100
+
+// number of fetch attempts shown by feed history when no count is given
+const defaultHistoryRows = 10
+
+// fetchStats collects what a single fetch attempt saw so it can be stored in feed_fetches
+type fetchStats struct {
+	startedAt time.Time
+	status    int
+	bytes     int
+	newItems  int
+	updated   int
+	skipped   int
+}
+
+func recordFetch(ctx context.Context, s *State, feedID uuid.UUID, stats *fetchStats, fetchErr error) error {
+	params := database.CreateFeedFetchParams{
+		ID:           uuid.New(),
+		FeedID:       feedID,
+		StartedAt:    stats.startedAt,
+		DurationMs:   time.Since(stats.startedAt).Milliseconds(),
+		HttpStatus:   sql.NullInt32{Int32: int32(stats.status), Valid: stats.status != 0},
+		Bytes:        int64(stats.bytes),
+		NewItems:     int32(stats.newItems),
+		UpdatedItems: int32(stats.updated),
+		SkippedItems: int32(stats.skipped),
+	}
+	if fetchErr != nil {
+		params.Error = sql.NullString{String: fetchErr.Error(), Valid: true}
+	}
+
+	err := s.Db.CreateFeedFetch(ctx, params)
+	if err != nil {
+		return errors.New("Error recording feed fetch")
+	}
+
+	err = s.Db.PruneFeedFetches(ctx, database.PruneFeedFetchesParams{
+		FeedID: feedID,
+		Limit:  fetchHistoryLimit,
+	})
+	if err != nil {
+		return errors.New("Error pruning feed fetch history")
+	}
+	return nil
+}
+
+func handlerFeedHistory(s *State, cmd Command) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Must include a feed url with this command")
+	}
+	limit := defaultHistoryRows
+	if len(cmd.Args) > 1 {
+		var err error
+		limit, err = strconv.Atoi(cmd.Args[1])
+		if err != nil || limit < 1 {
+			return errors.New("Number of fetches must be a positive integer")
+		}
+	}
+
+	ctx := context.Background()
+	feed, err := findFeed(ctx, s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+
+	fetches, err := s.Db.GetFeedFetches(ctx, database.GetFeedFetchesParams{
+		FeedID: feed.ID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return errors.New("Unable to get fetch history from database")
+	}
+	if len(fetches) == 0 {
+		fmt.Println("No fetches recorded for this feed")
+		return nil
+	}
+
+	for _, f := range fetches {
+		status := "---"
+		if f.HttpStatus.Valid {
+			status = strconv.Itoa(int(f.HttpStatus.Int32))
+		}
+		fmt.Printf("%s  %s  %6dms  %8d bytes  new %d, updated %d, skipped %d\n",
+			f.StartedAt.Local().Format(time.DateTime), status, f.DurationMs, f.Bytes,
+			f.NewItems, f.UpdatedItems, f.SkippedItems)
+		if f.Error.Valid {
+			fmt.Printf("    error: %s\n", f.Error.String)
+		}
+	}
+	return nil
+}
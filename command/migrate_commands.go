@@ -0,0 +1,86 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/luckyhut/gator/migrate"
+	"github.com/luckyhut/gator/sql/schema"
+	"time"
+)
+
+// subcommands of gator migrate
+var migrateCommands = map[string]func(context.Context, *State, []migrate.Migration) error{
+	"up":      migrateUp,
+	"down":    migrateDown,
+	"status":  migrateStatus,
+	"version": migrateVersion,
+}
+
+// Migrations returns the schema migrations built into gator
+func Migrations() ([]migrate.Migration, error) {
+	return migrate.Load(schema.Migrations)
+}
+
+func HandlerMigrate(s *State, cmd Command) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Must include a subcommand: up, down, status or version")
+	}
+	f, exists := migrateCommands[cmd.Args[0]]
+	if !exists {
+		return fmt.Errorf("Unknown migrate subcommand %s", cmd.Args[0])
+	}
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+	return f(context.Background(), s, migrations)
+}
+
+func migrateUp(ctx context.Context, s *State, migrations []migrate.Migration) error {
+	applied, err := migrate.Up(ctx, s.Conn, migrations)
+	for _, m := range applied {
+		fmt.Println("applied", m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Println("Database is up to date")
+	}
+	return nil
+}
+
+func migrateDown(ctx context.Context, s *State, migrations []migrate.Migration) error {
+	m, err := migrate.Down(ctx, s.Conn, migrations)
+	if err != nil {
+		return err
+	}
+	fmt.Println("rolled back", m.Name)
+	return nil
+}
+
+func migrateStatus(ctx context.Context, s *State, migrations []migrate.Migration) error {
+	statuses, err := migrate.GetStatus(ctx, s.Conn, migrations)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%-19s  %s\n", "Applied At", "Migration")
+	for _, status := range statuses {
+		appliedAt := "Pending"
+		if status.AppliedAt.Valid {
+			appliedAt = status.AppliedAt.Time.Format(time.DateTime)
+		}
+		fmt.Printf("%-19s  %s\n", appliedAt, status.Name)
+	}
+	return nil
+}
+
+func migrateVersion(ctx context.Context, s *State, migrations []migrate.Migration) error {
+	version, err := migrate.Version(ctx, s.Conn)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("version %d, latest %d\n", version, migrate.Latest(migrations))
+	return nil
+}
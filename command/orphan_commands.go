@@ -0,0 +1,153 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/luckyhut/gator/config"
+	"github.com/luckyhut/gator/database"
+	"github.com/luckyhut/gator/metrics"
+	"time"
+)
+
+// how long a feed nobody follows is kept before its posts and the feed itself are removed
+const defaultOrphanGrace = 7 * day
+
+// orphanResult is what the orphan cleanup removed, or would remove
+type orphanResult struct {
+	// urls of the feeds removed
+	feeds []string
+	// urls of orphaned feeds kept because someone starred one of their posts
+	kept  []string
+	posts int64
+}
+
+// orphanGrace reads orphan_grace from the config file
+func orphanGrace(conf *config.Config) (time.Duration, error) {
+	if conf.OrphanGrace == "" {
+		return defaultOrphanGrace, nil
+	}
+	grace, err := parseAge(conf.OrphanGrace)
+	if err != nil {
+		return 0, fmt.Errorf("orphan_grace: %w", err)
+	}
+	return grace, nil
+}
+
+// removeOrphans deletes feeds that have had no followers for longer than the grace
+// period, along with their posts. Starred posts are kept, and so is their feed, which
+// stays unfetched until someone follows it again. With dryRun nothing is changed.
+func removeOrphans(ctx context.Context, s *State, dryRun bool) (*orphanResult, error) {
+	grace, err := orphanGrace(s.Config)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if !dryRun {
+		// the grace period runs from the first pass that finds a feed without followers
+		_, err = s.Db.ClearOrphanedFeeds(ctx)
+		if err != nil {
+			return nil, errors.New("Error updating orphaned feeds")
+		}
+		_, err = s.Db.MarkOrphanedFeeds(ctx, sql.NullTime{Time: now, Valid: true})
+		if err != nil {
+			return nil, errors.New("Error updating orphaned feeds")
+		}
+	}
+
+	feeds, err := s.Db.GetOrphanedFeeds(ctx, now.Add(-grace))
+	if err != nil {
+		return nil, errors.New("Error finding orphaned feeds")
+	}
+	result := &orphanResult{}
+	for _, feed := range feeds {
+		if dryRun {
+			result.feeds = append(result.feeds, feed.Url.String)
+			continue
+		}
+		var posts, removed int64
+		err = inTx(ctx, s, func(q database.Querier) error {
+			// locking the feed makes a concurrent follow wait for this transaction, and the
+			// deletes below leave the feed alone if someone followed it since it was found
+			err := q.LockFeed(ctx, feed.ID)
+			if err != nil {
+				return errors.New("Error locking feed")
+			}
+			posts, err = q.DeleteFeedPosts(ctx, feed.ID)
+			if err != nil {
+				return errors.New("Error deleting posts")
+			}
+			removed, err = q.DeleteFeed(ctx, feed.ID)
+			if err != nil {
+				return errors.New("Error deleting feed")
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		metrics.PostsPruned.Add(float64(posts))
+		result.posts += posts
+		if removed > 0 {
+			result.feeds = append(result.feeds, feed.Url.String)
+		} else {
+			result.kept = append(result.kept, feed.Url.String)
+		}
+	}
+	return result, nil
+}
+
+// handlerFeedRm deletes everyone's follows of a feed and its posts, then the feed itself.
+// Like the orphan cleanup it keeps starred posts, and with them the feed, which stays
+// unfetched until someone follows it again. Only the user who added the feed or an admin may.
+func handlerFeedRm(s *State, cmd Command) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Must include a feed url with this command")
+	}
+	ctx := context.Background()
+	user, err := s.Db.GetUser(ctx, s.Config.CurrentUserName)
+	if err != nil {
+		return errors.New("User is not registered")
+	}
+	feed, err := findFeed(ctx, s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	feedUrl := feed.Url.String
+	if !mayManageFeed(s, user, &feed) {
+		return errors.New("Only the user who added a feed or an admin can remove it")
+	}
+
+	// posts don't cascade from feeds, and neither they nor the feed are deleted while
+	// it has followers, so the follows go first
+	var posts, removed int64
+	err = inTx(ctx, s, func(q database.Querier) error {
+		err := q.LockFeed(ctx, feed.ID)
+		if err != nil {
+			return errors.New("Error locking feed")
+		}
+		_, err = q.DeleteFeedFollows(ctx, feed.ID)
+		if err != nil {
+			return errors.New("Error removing follows")
+		}
+		posts, err = q.DeleteFeedPosts(ctx, feed.ID)
+		if err != nil {
+			return errors.New("Error deleting posts")
+		}
+		removed, err = q.DeleteFeed(ctx, feed.ID)
+		if err != nil {
+			return errors.New("Error deleting feed")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		fmt.Printf("Removed the follows and %d posts of %s, kept the feed for its starred posts\n", posts, feedUrl)
+		return nil
+	}
+	fmt.Printf("Removed %s and its %d posts\n", feedUrl, posts)
+	return nil
+}
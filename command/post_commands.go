@@ -0,0 +1,93 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/database"
+	"github.com/luckyhut/gator/rss"
+	"strconv"
+	"strings"
+)
+
+// number of categories shown by gator categories when no count is given
+const defaultCategoryRows = 20
+
+// storePostMetadata replaces the categories and authors stored for a post with the ones in item
+func storePostMetadata(ctx context.Context, q database.Querier, postID uuid.UUID, item *rss.RSSItem) error {
+	err := q.DeletePostCategories(ctx, postID)
+	if err != nil {
+		return errors.New("Error clearing post categories")
+	}
+	for _, category := range item.Categories() {
+		err = q.CreatePostCategory(ctx, database.CreatePostCategoryParams{
+			PostID:   postID,
+			Category: normalizeCategory(category),
+		})
+		if err != nil {
+			return errors.New("Error adding post category to database")
+		}
+	}
+
+	err = q.DeletePostAuthors(ctx, postID)
+	if err != nil {
+		return errors.New("Error clearing post authors")
+	}
+	for _, name := range item.Authors() {
+		authorID, err := q.UpsertAuthor(ctx, database.UpsertAuthorParams{
+			ID:         uuid.New(),
+			Name:       name,
+			Normalized: normalizeAuthor(name),
+		})
+		if err != nil {
+			return errors.New("Error adding author to database")
+		}
+		err = q.CreatePostAuthor(ctx, database.CreatePostAuthorParams{
+			PostID:   postID,
+			AuthorID: authorID,
+		})
+		if err != nil {
+			return errors.New("Error adding post author to database")
+		}
+	}
+	return nil
+}
+
+// categories are stored lowercased so "Go" and "go" count as one
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.Join(strings.Fields(category), " "))
+}
+
+// authors are matched on their lowercased display name
+func normalizeAuthor(author string) string {
+	return strings.ToLower(rss.AuthorName(author))
+}
+
+func HandlerCategories(s *State, cmd Command, user database.User) error {
+	limit := defaultCategoryRows
+	if len(cmd.Args) > 0 {
+		var err error
+		limit, err = strconv.Atoi(cmd.Args[0])
+		if err != nil || limit < 1 {
+			return errors.New("Number of categories must be a positive integer")
+		}
+	}
+
+	categories, err := s.Db.GetTopCategoriesForUser(context.Background(), database.GetTopCategoriesForUserParams{
+		UserID: user.ID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return errors.New("Unable to get categories from database")
+	}
+	if len(categories) == 0 {
+		fmt.Println("No categories found in followed feeds")
+		return nil
+	}
+
+	for _, c := range categories {
+		fmt.Printf("%6d  %s\n", c.PostCount, c.Category)
+	}
+	return nil
+}
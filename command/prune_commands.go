@@ -0,0 +1,239 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/config"
+	"github.com/luckyhut/gator/database"
+	"github.com/luckyhut/gator/metrics"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// how often a running agg applies the retention limits
+const pruneInterval = time.Hour
+
+// posts are deleted this many at a time, so one delete doesn't hold locks on a huge set
+const pruneBatch = 1000
+
+const day = 24 * time.Hour
+
+// pruneResult is how many posts retention removed, or would remove, from each feed
+type pruneResult struct {
+	feeds []string
+	posts map[string]int
+	total int
+}
+
+// parseAge reads a retention age like "7d", "2w" or anything time.ParseDuration accepts
+func parseAge(age string) (time.Duration, error) {
+	var unit time.Duration
+	switch {
+	case strings.HasSuffix(age, "d"):
+		unit = day
+	case strings.HasSuffix(age, "w"):
+		unit = 7 * day
+	default:
+		d, err := time.ParseDuration(age)
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("Invalid age %s, use something like 7d, 2w or 12h", age)
+		}
+		return d, nil
+	}
+	n, err := strconv.Atoi(strings.TrimRight(age, "dw"))
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("Invalid age %s, use something like 7d, 2w or 12h", age)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// formatAge prints a retention age in days when it is a whole number of them
+func formatAge(seconds int64) string {
+	d := time.Duration(seconds) * time.Second
+	if d%day == 0 {
+		return fmt.Sprintf("%dd", d/day)
+	}
+	return d.String()
+}
+
+// retentionDefaults turns the config file's limits into the ones feeds without their own use
+func retentionDefaults(conf *config.Config) (database.GetPostsToPruneParams, error) {
+	var params database.GetPostsToPruneParams
+	if conf.RetentionMaxAge != "" {
+		age, err := parseAge(conf.RetentionMaxAge)
+		if err != nil {
+			return params, fmt.Errorf("retention_max_age: %w", err)
+		}
+		params.DefaultMaxAgeSeconds = sql.NullInt64{Int64: int64(age.Seconds()), Valid: true}
+	}
+	if conf.RetentionMaxPosts > 0 {
+		params.DefaultMaxPosts = sql.NullInt32{Int32: int32(conf.RetentionMaxPosts), Valid: true}
+	}
+	return params, nil
+}
+
+// prunePosts deletes the posts that are past their feed's retention limits, or with
+// dryRun only counts them. Starred posts are never pruned. A post's age counts from
+// when gator stored it, not from its publish date, which feeds don't always give.
+func prunePosts(ctx context.Context, s *State, dryRun bool) (*pruneResult, error) {
+	params, err := retentionDefaults(s.Config)
+	if err != nil {
+		return nil, err
+	}
+	// created_at is written from Go in UTC, so the cutoff is too
+	params.Now = time.Now().UTC()
+	rows, err := s.Db.GetPostsToPrune(ctx, params)
+	if err != nil {
+		return nil, errors.New("Error finding posts to prune")
+	}
+
+	result := &pruneResult{posts: make(map[string]int)}
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		if _, ok := result.posts[row.FeedUrl.String]; !ok {
+			result.feeds = append(result.feeds, row.FeedUrl.String)
+		}
+		result.posts[row.FeedUrl.String]++
+		ids = append(ids, row.ID)
+	}
+	result.total = len(ids)
+	if dryRun {
+		return result, nil
+	}
+
+	var deleted int64
+	for len(ids) > 0 {
+		batch := ids[:min(pruneBatch, len(ids))]
+		ids = ids[len(batch):]
+		n, err := s.Db.DeletePosts(ctx, batch)
+		if err != nil {
+			return nil, errors.New("Error deleting posts")
+		}
+		deleted += n
+	}
+	metrics.PostsPruned.Add(float64(deleted))
+	// posts starred since they were counted are kept
+	result.total = int(deleted)
+	return result, nil
+}
+
+func HandlerPrune(s *State, cmd Command) error {
+	flags := flag.NewFlagSet("prune", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only show how many posts would be deleted")
+	err := flags.Parse(cmd.Args)
+	if err != nil {
+		return err
+	}
+
+	result, err := prunePosts(context.Background(), s, *dryRun)
+	if err != nil {
+		return err
+	}
+	for _, feed := range result.feeds {
+		fmt.Printf("%6d  %s\n", result.posts[feed], feed)
+	}
+	if *dryRun {
+		fmt.Printf("%d posts would be deleted\n", result.total)
+	} else {
+		fmt.Printf("%d posts deleted\n", result.total)
+	}
+
+	orphans, err := removeOrphans(context.Background(), s, *dryRun)
+	if err != nil {
+		return err
+	}
+	for _, url := range orphans.feeds {
+		if *dryRun {
+			fmt.Printf("would remove orphaned feed %s, unless some of its posts are starred\n", url)
+		} else {
+			fmt.Printf("removed orphaned feed %s\n", url)
+		}
+	}
+	for _, url := range orphans.kept {
+		fmt.Printf("kept orphaned feed %s for its starred posts\n", url)
+	}
+	if orphans.posts > 0 {
+		fmt.Printf("%d posts of orphaned feeds deleted\n", orphans.posts)
+	}
+	return nil
+}
+
+// handlerFeedRetention shows or sets a feed's own retention limits, which override the config file's
+func handlerFeedRetention(s *State, cmd Command) error {
+	flags := flag.NewFlagSet("feed retention", flag.ContinueOnError)
+	maxAge := flags.String("max-age", "", "delete posts older than this, like 7d, or none to use the default")
+	maxPosts := flags.String("max-posts", "", "keep only this many of the newest posts, or none to use the default")
+	err := flags.Parse(cmd.Args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("Must include a feed url with this command")
+	}
+
+	ctx := context.Background()
+	feed, err := findFeed(ctx, s, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *maxAge != "" || *maxPosts != "" {
+		user, err := s.Db.GetUser(ctx, s.Config.CurrentUserName)
+		if err != nil {
+			return errors.New("User is not registered")
+		}
+		if !mayManageFeed(s, user, &feed) {
+			return errors.New("Only the user who added a feed or an admin can change its retention")
+		}
+
+		params := database.SetFeedRetentionParams{
+			ID:                     feed.ID,
+			RetentionMaxAgeSeconds: feed.RetentionMaxAgeSeconds,
+			RetentionMaxPosts:      feed.RetentionMaxPosts,
+		}
+		switch *maxAge {
+		case "":
+		case "none":
+			params.RetentionMaxAgeSeconds = sql.NullInt64{}
+		default:
+			age, err := parseAge(*maxAge)
+			if err != nil {
+				return err
+			}
+			params.RetentionMaxAgeSeconds = sql.NullInt64{Int64: int64(age.Seconds()), Valid: true}
+		}
+		switch *maxPosts {
+		case "":
+		case "none":
+			params.RetentionMaxPosts = sql.NullInt32{}
+		default:
+			n, err := strconv.Atoi(*maxPosts)
+			if err != nil || n < 1 {
+				return errors.New("--max-posts must be a positive integer or none")
+			}
+			params.RetentionMaxPosts = sql.NullInt32{Int32: int32(n), Valid: true}
+		}
+		err = s.Db.SetFeedRetention(ctx, params)
+		if err != nil {
+			return errors.New("Error saving feed retention")
+		}
+		feed.RetentionMaxAgeSeconds = params.RetentionMaxAgeSeconds
+		feed.RetentionMaxPosts = params.RetentionMaxPosts
+	}
+
+	age := "default"
+	if feed.RetentionMaxAgeSeconds.Valid {
+		age = formatAge(feed.RetentionMaxAgeSeconds.Int64)
+	}
+	posts := "default"
+	if feed.RetentionMaxPosts.Valid {
+		posts = strconv.Itoa(int(feed.RetentionMaxPosts.Int32))
+	}
+	fmt.Printf("max age:   %s\nmax posts: %s\n", age, posts)
+	return nil
+}
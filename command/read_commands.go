@@ -0,0 +1,184 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/database"
+	"github.com/luckyhut/gator/rss"
+	"strings"
+	"time"
+)
+
+// how many characters of a post's id browse shows, and the least a command accepts
+const shortIDLength = 8
+
+// shortID is the start of a post's id, enough to tell posts apart in practice
+func shortID(id uuid.UUID) string {
+	return id.String()[:shortIDLength]
+}
+
+// findPostID looks a post up by its url, or by its id or the start of it as shown by browse
+func findPostID(ctx context.Context, s *State, ref string) (uuid.UUID, error) {
+	if !isIDPrefix(ref) {
+		id, err := s.Db.GetPostByUrl(ctx, ref)
+		if errors.Is(err, sql.ErrNoRows) {
+			return id, fmt.Errorf("No post with url %s", ref)
+		}
+		if err != nil {
+			return id, errors.New("Error getting post from database")
+		}
+		return id, nil
+	}
+
+	ids, err := s.Db.GetPostsByIdPrefix(ctx, strings.ToLower(ref))
+	if err != nil {
+		return uuid.Nil, errors.New("Error getting post from database")
+	}
+	switch len(ids) {
+	case 0:
+		return uuid.Nil, fmt.Errorf("No post with id %s", ref)
+	case 1:
+		return ids[0], nil
+	}
+	return uuid.Nil, fmt.Errorf("More than one post has an id starting with %s, use more of it", ref)
+}
+
+func isIDPrefix(ref string) bool {
+	if len(ref) < shortIDLength || len(ref) > 36 {
+		return false
+	}
+	for _, r := range strings.ToLower(ref) {
+		if !strings.ContainsRune("0123456789abcdef-", r) {
+			return false
+		}
+	}
+	return true
+}
+
+func HandlerRead(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Must include a post id or url with this command")
+	}
+	ctx := context.Background()
+	postID, err := findPostID(ctx, s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	err = s.Db.MarkPostRead(ctx, database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: postID,
+		ReadAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return errors.New("Error marking post read")
+	}
+	return nil
+}
+
+func HandlerUnread(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Must include a post id or url with this command")
+	}
+	ctx := context.Background()
+	postID, err := findPostID(ctx, s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	_, err = s.Db.MarkPostUnread(ctx, database.MarkPostUnreadParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		return errors.New("Error marking post unread")
+	}
+	return nil
+}
+
+func HandlerMarkAllRead(s *State, cmd Command, user database.User) error {
+	flags := flag.NewFlagSet("mark-all-read", flag.ContinueOnError)
+	feedUrl := flags.String("feed", "", "only mark posts from this feed")
+	before := flags.String("before", "", "only mark posts published before this date, like 2025-01-31")
+	err := flags.Parse(cmd.Args)
+	if err != nil {
+		return err
+	}
+
+	params := database.MarkAllReadParams{
+		ReadAt: time.Now().UTC(),
+		UserID: user.ID,
+	}
+	if *feedUrl != "" {
+		params.FeedUrl = sql.NullString{String: rewriteFeedUrl(*feedUrl), Valid: true}
+	}
+	if *before != "" {
+		t, err := parseDate(*before)
+		if err != nil {
+			return err
+		}
+		n, err := markReadBefore(context.Background(), s, params, t)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Marked %d posts read\n", n)
+		return nil
+	}
+
+	n, err := s.Db.MarkAllRead(context.Background(), params)
+	if err != nil {
+		return errors.New("Error marking posts read")
+	}
+	fmt.Printf("Marked %d posts read\n", n)
+	return nil
+}
+
+// markReadBefore marks the unread posts published before t read. published_at is whatever
+// text the feed gave, so it is parsed here, and posts whose date can't be read go by when
+// they were stored instead.
+func markReadBefore(ctx context.Context, s *State, params database.MarkAllReadParams, t time.Time) (int, error) {
+	n := 0
+	err := inTx(ctx, s, func(q database.Querier) error {
+		posts, err := q.GetUnreadPosts(ctx, database.GetUnreadPostsParams{
+			UserID:  params.UserID,
+			FeedUrl: params.FeedUrl,
+		})
+		if err != nil {
+			return errors.New("Error getting posts from database")
+		}
+		for _, post := range posts {
+			published, err := rss.ParseDate(post.PublishedAt.String)
+			if err != nil {
+				published = post.CreatedAt
+			}
+			if !published.Before(t) {
+				continue
+			}
+			err = q.MarkPostRead(ctx, database.MarkPostReadParams{
+				UserID: params.UserID,
+				PostID: post.ID,
+				ReadAt: params.ReadAt,
+			})
+			if err != nil {
+				return errors.New("Error marking posts read")
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// parseDate reads a local date or time given on the command line and returns it in UTC,
+// the zone times are stored in
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range []string{time.DateOnly, time.DateTime, "2006-01-02T15:04", time.RFC3339} {
+		t, err := time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid date %s, use something like 2025-01-31 or \"2025-01-31 18:00\"", value)
+}
@@ -0,0 +1,33 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/luckyhut/gator/database"
+	"strings"
+	"time"
+)
+
+// HandlerRename sets the title the user sees for a feed they follow in place of the
+// feed's name. Without a title it goes back to the feed's name.
+func HandlerRename(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Usage: gator rename <url> [title]")
+	}
+	ctx := context.Background()
+	follow, err := findFollow(ctx, s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	title := strings.TrimSpace(strings.Join(cmd.Args[1:], " "))
+	err = s.Db.RenameFeedFollow(ctx, database.RenameFeedFollowParams{
+		ID:        follow.ID,
+		Title:     sql.NullString{String: title, Valid: title != ""},
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return errors.New("Error renaming feed")
+	}
+	return nil
+}
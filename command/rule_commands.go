@@ -0,0 +1,201 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/database"
+	"github.com/luckyhut/gator/rules"
+	"strconv"
+	"time"
+)
+
+// subcommands of gator rule
+var ruleCommands = map[string]func(*State, Command, database.User) error{
+	"add":  handlerRuleAdd,
+	"list": handlerRuleList,
+	"rm":   handlerRuleRemove,
+	"test": handlerRuleTest,
+}
+
+func HandlerRule(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Must include a subcommand with this command")
+	}
+	f, exists := ruleCommands[cmd.Args[0]]
+	if !exists {
+		return fmt.Errorf("Unknown rule subcommand %s", cmd.Args[0])
+	}
+	return f(s, Command{Name: cmd.Args[0], Args: cmd.Args[1:]}, user)
+}
+
+// gator rule add <url> <action> <field> <pattern> [replacement]
+func handlerRuleAdd(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) < 4 {
+		return errors.New("Usage: rule add <url> <drop|keep|replace|prefix> <title|link|description> <pattern> [replacement]")
+	}
+	rule := rules.Rule{
+		Action:  cmd.Args[1],
+		Field:   cmd.Args[2],
+		Pattern: cmd.Args[3],
+	}
+	if len(cmd.Args) > 4 {
+		rule.Replacement = cmd.Args[4]
+	}
+	err := rule.Validate()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	feed, err := findFeed(ctx, s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	if !mayManageFeed(s, user, &feed) {
+		return errors.New("Only the user who added a feed or an admin can change its rules")
+	}
+
+	created, err := s.Db.CreateFeedRule(ctx, database.CreateFeedRuleParams{
+		ID:          uuid.New(),
+		CreatedAt:   time.Now().UTC(),
+		FeedID:      feed.ID,
+		UserID:      user.ID,
+		Action:      rule.Action,
+		Field:       rule.Field,
+		Pattern:     rule.Pattern,
+		Replacement: rule.Replacement,
+	})
+	if err != nil {
+		return errors.New("Could not create rule")
+	}
+	fmt.Printf("Added rule %d: %s\n", created.Position, rule)
+	return nil
+}
+
+func handlerRuleList(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Must include a feed url with this command")
+	}
+	ctx := context.Background()
+	feed, err := findFeed(ctx, s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	feedRules, err := s.Db.GetFeedRules(ctx, feed.ID)
+	if err != nil {
+		return errors.New("Unable to get rules from database")
+	}
+	if len(feedRules) == 0 {
+		fmt.Println("No rules for this feed")
+		return nil
+	}
+	for _, r := range feedRules {
+		fmt.Printf("%3d  %s\n", r.Position, toRule(r))
+	}
+	return nil
+}
+
+func handlerRuleRemove(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) < 2 {
+		return errors.New("Must include a feed url and rule number with this command")
+	}
+	position, err := strconv.Atoi(cmd.Args[1])
+	if err != nil {
+		return errors.New("Rule number must be an integer")
+	}
+	ctx := context.Background()
+	feed, err := findFeed(ctx, s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	if !mayManageFeed(s, user, &feed) {
+		return errors.New("Only the user who added a feed or an admin can change its rules")
+	}
+	removed, err := s.Db.DeleteFeedRule(ctx, database.DeleteFeedRuleParams{
+		FeedID:   feed.ID,
+		Position: int32(position),
+	})
+	if err != nil {
+		return errors.New("Could not remove rule")
+	}
+	if removed == 0 {
+		return fmt.Errorf("Feed has no rule %d", position)
+	}
+	fmt.Printf("Removed rule %d\n", position)
+	return nil
+}
+
+// handlerRuleTest fetches the feed and shows what its rules would do, without storing anything
+func handlerRuleTest(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Must include a feed url with this command")
+	}
+	ctx := context.Background()
+	dbFeed, err := findFeed(ctx, s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	pipeline, feedRules, err := feedPipeline(ctx, s, dbFeed.ID)
+	if err != nil {
+		return err
+	}
+	feed, err := fetchSource(ctx, s, &dbFeed, &fetchStats{})
+	if err != nil {
+		return err
+	}
+
+	for _, original := range feed.Channel.Item {
+		item := original
+		dropped := pipeline.Apply(&item)
+		if dropped >= 0 {
+			fmt.Printf("drop  %s (rule %d)\n", original.Title, feedRules[dropped].Position)
+			continue
+		}
+		if item.Title == original.Title && item.Link == original.Link && item.Description == original.Description {
+			fmt.Printf("keep  %s\n", item.Title)
+			continue
+		}
+		fmt.Printf("edit  %s\n", item.Title)
+		printChange("title", original.Title, item.Title)
+		printChange("link", original.Link, item.Link)
+		printChange("description", original.Description, item.Description)
+	}
+	return nil
+}
+
+func printChange(field, before, after string) {
+	if before == after {
+		return
+	}
+	fmt.Printf("      %s: %q\n", field, before)
+	fmt.Printf("      %*s %q\n", len(field)+1, "->", after)
+}
+
+// feedPipeline loads a feed's rules from the database and compiles them. The pipeline's
+// rule indexes are indexes into the returned rules.
+func feedPipeline(ctx context.Context, s *State, feedID uuid.UUID) (*rules.Pipeline, []database.FeedRule, error) {
+	feedRules, err := s.Db.GetFeedRules(ctx, feedID)
+	if err != nil {
+		return nil, nil, errors.New("Unable to get rules from database")
+	}
+	list := make([]rules.Rule, 0, len(feedRules))
+	for _, r := range feedRules {
+		list = append(list, toRule(r))
+	}
+	pipeline, err := rules.Compile(list)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pipeline, feedRules, nil
+}
+
+func toRule(r database.FeedRule) rules.Rule {
+	return rules.Rule{
+		Action:      r.Action,
+		Field:       r.Field,
+		Pattern:     r.Pattern,
+		Replacement: r.Replacement,
+	}
+}
@@ -0,0 +1,160 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/database"
+	"github.com/luckyhut/gator/scrape"
+	"time"
+)
+
+// subcommands of gator scrape
+var scrapeCommands = map[string]func(*State, Command, database.User) error{
+	"add":  handlerScrapeAdd,
+	"test": handlerScrapeTest,
+}
+
+func HandlerScrape(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Must include a subcommand with this command")
+	}
+	f, exists := scrapeCommands[cmd.Args[0]]
+	if !exists {
+		return fmt.Errorf("Unknown scrape subcommand %s", cmd.Args[0])
+	}
+	return f(s, Command{Name: cmd.Args[0], Args: cmd.Args[1:]}, user)
+}
+
+// parseSelectors reads the --item, --title, --link, --date and --summary flags
+func parseSelectors(name string, args []string) (scrape.Selectors, error) {
+	var sel scrape.Selectors
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.StringVar(&sel.Item, "item", "", "selector for each item on the page (required)")
+	flags.StringVar(&sel.Title, "title", "", "selector for the title inside an item")
+	flags.StringVar(&sel.Link, "link", "", "selector for the link inside an item")
+	flags.StringVar(&sel.Date, "date", "", "selector for the date inside an item")
+	flags.StringVar(&sel.Summary, "summary", "", "selector for the summary inside an item")
+	err := flags.Parse(args)
+	if err != nil {
+		return sel, err
+	}
+	if flags.NArg() > 0 {
+		return sel, fmt.Errorf("Unexpected argument %s", flags.Arg(0))
+	}
+	return sel, nil
+}
+
+// gator scrape add <name> <page url> --item <selector> [--title ...] [--link ...] [--date ...] [--summary ...]
+func handlerScrapeAdd(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) < 2 {
+		return errors.New("Usage: scrape add <name> <page url> --item <selector> [--title <selector>] [--link <selector>] [--date <selector>] [--summary <selector>]")
+	}
+	sel, err := parseSelectors("scrape add", cmd.Args[2:])
+	if err != nil {
+		return err
+	}
+	err = sel.Validate()
+	if err != nil {
+		return err
+	}
+
+	// the feed, its scraper and its follow are created together, so a failure leaves no feed behind
+	ctx := context.Background()
+	curTime := time.Now().UTC()
+	err = inTx(ctx, s, func(q database.Querier) error {
+		feed, err := q.CreateFeed(ctx, database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: curTime,
+			UpdatedAt: curTime,
+			Name:      sql.NullString{String: cmd.Args[0], Valid: true},
+			Url:       sql.NullString{String: cmd.Args[1], Valid: true},
+			UserID:    uuid.NullUUID{UUID: user.ID, Valid: true},
+			Kind:      kindScrape,
+		})
+		if err != nil {
+			return errors.New("Could not create feed, is the page already a feed?")
+		}
+
+		err = q.UpsertFeedScraper(ctx, database.UpsertFeedScraperParams{
+			FeedID:          feed.ID,
+			ItemSelector:    sel.Item,
+			TitleSelector:   sel.Title,
+			LinkSelector:    sel.Link,
+			DateSelector:    sel.Date,
+			SummarySelector: sel.Summary,
+		})
+		if err != nil {
+			return errors.New("Could not store scraper definition")
+		}
+
+		err = q.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: curTime,
+			UpdatedAt: curTime,
+			UserID:    user.ID,
+			FeedID:    feed.ID,
+		})
+		if err != nil {
+			return errors.New("Could not create FeedFollow record")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	notifyFeedAdded(s, cmd.Args[1])
+	fmt.Printf("Added scraped feed %s\n", cmd.Args[0])
+	return nil
+}
+
+// gator scrape test <page url> [selectors], without selectors the stored definition is used
+func handlerScrapeTest(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Usage: scrape test <page url> [--item <selector>] [--title <selector>] [--link <selector>] [--date <selector>] [--summary <selector>]")
+	}
+	pageURL := cmd.Args[0]
+	sel, err := parseSelectors("scrape test", cmd.Args[1:])
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if sel == (scrape.Selectors{}) {
+		feed, err := findFeed(ctx, s, pageURL)
+		if err != nil || feed.Kind != kindScrape {
+			return errors.New("Page is not a scraped feed, give selectors to test")
+		}
+		def, err := s.Db.GetFeedScraper(ctx, feed.ID)
+		if err != nil {
+			return errors.New("Error getting scraper definition from database")
+		}
+		sel = toSelectors(def)
+	}
+
+	feed, err := fetchScraped(ctx, pageURL, sel, &fetchStats{})
+	if err != nil {
+		return err
+	}
+	if len(feed.Channel.Item) == 0 {
+		fmt.Println("No items matched")
+		return nil
+	}
+	fmt.Printf("%s: %d items\n", feed.Channel.Title, len(feed.Channel.Item))
+	for _, item := range feed.Channel.Item {
+		fmt.Println("---------------------------------------------------")
+		fmt.Printf("title:   %s\n", item.Title)
+		fmt.Printf("link:    %s\n", item.Link)
+		if item.PubDate != "" {
+			fmt.Printf("date:    %s\n", item.PubDate)
+		}
+		if item.Description != "" {
+			fmt.Printf("summary: %s\n", item.Description)
+		}
+	}
+	fmt.Println("---------------------------------------------------")
+	return nil
+}
@@ -0,0 +1,65 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/luckyhut/gator/database"
+	"github.com/luckyhut/gator/search"
+	"strings"
+)
+
+// how many results gator search shows without --limit
+const defaultSearchResults = 10
+
+func HandlerSearch(s *State, cmd Command, user database.User) error {
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	limit := flags.Int("limit", defaultSearchResults, "show at most this many posts")
+	err := flags.Parse(cmd.Args)
+	if err != nil {
+		return err
+	}
+	if *limit < 1 {
+		return errors.New("--limit must be a positive integer")
+	}
+	if flags.NArg() == 0 {
+		return errors.New("Must include something to search for")
+	}
+
+	query, err := search.Parse(strings.Join(flags.Args(), " "))
+	if err != nil {
+		return err
+	}
+	results, err := s.Db.SearchPosts(context.Background(), database.SearchPostsParams{
+		Query:  query.TSQuery(),
+		UserID: user.ID,
+		Limit:  int32(*limit),
+	})
+	if err != nil {
+		return errors.New("Unable to search posts")
+	}
+	if len(results) == 0 {
+		fmt.Println("No posts found")
+		return nil
+	}
+
+	for i, r := range results {
+		fmt.Printf("%d. %s\n", i+1, oneLine(r.TitleHeadline))
+		source := r.FeedName.String
+		if r.PublishedAt.String != "" {
+			source += ", " + r.PublishedAt.String
+		}
+		fmt.Printf("   %s\n", source)
+		fmt.Printf("   %s\n", r.Url)
+		if headline := oneLine(r.Headline); headline != "" {
+			fmt.Printf("   %s\n", headline)
+		}
+	}
+	return nil
+}
+
+// oneLine collapses runs of whitespace, including newlines, to single spaces
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,206 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/luckyhut/gator/config"
+	"github.com/luckyhut/gator/database"
+	"github.com/luckyhut/gator/mailbox"
+	"github.com/luckyhut/gator/metrics"
+	"github.com/luckyhut/gator/rss"
+	"github.com/luckyhut/gator/scrape"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// kinds of feed source, stored in feeds.kind
+const (
+	kindRSS     = "rss"
+	kindScrape  = "scrape"
+	kindExec    = "exec"
+	kindMailbox = "mailbox"
+)
+
+// prefixes of feed urls that name something local instead of a web address
+const (
+	execPrefix    = "exec:"
+	mboxPrefix    = "mbox:"
+	maildirPrefix = "maildir:"
+)
+
+// how long an exec: feed's program may run before it is killed
+const execTimeout = time.Minute
+
+// how long to wait for a program's output to close after it exits or is killed, in
+// case something it started still holds it open
+const execWaitDelay = 5 * time.Second
+
+// sourceKind works out the kind of a feed added with addfeed from its url
+func sourceKind(feedUrl string) string {
+	switch {
+	case strings.HasPrefix(feedUrl, execPrefix):
+		return kindExec
+	case strings.HasPrefix(feedUrl, mboxPrefix), strings.HasPrefix(feedUrl, maildirPrefix):
+		return kindMailbox
+	}
+	return kindRSS
+}
+
+// fetchSource gets the current items of a feed from wherever its kind says they live
+func fetchSource(ctx context.Context, s *State, feed *database.Feed, stats *fetchStats) (*rss.RSSFeed, error) {
+	switch feed.Kind {
+	case kindScrape:
+		def, err := s.Db.GetFeedScraper(ctx, feed.ID)
+		if err != nil {
+			return nil, errors.New("Error getting scraper definition from database")
+		}
+		return fetchScraped(ctx, feed.Url.String, toSelectors(def), stats)
+	case kindExec:
+		return fetchExec(ctx, s.Config, feed.Url.String, stats)
+	case kindMailbox:
+		return fetchMailbox(s.Config, feed.Url.String, stats)
+	}
+	return fetchFeed(ctx, feed.Url.String, stats)
+}
+
+func fetchScraped(ctx context.Context, pageURL string, sel scrape.Selectors, stats *fetchStats) (*rss.RSSFeed, error) {
+	body, err := fetchBody(ctx, pageURL, stats)
+	if err != nil {
+		return nil, err
+	}
+	feed, err := scrape.Parse(body, pageURL, sel)
+	if err != nil {
+		metrics.ParseErrors.Inc("html")
+	}
+	return feed, err
+}
+
+// fetchExec runs the program named by an exec: url and parses its stdout as a feed
+func fetchExec(ctx context.Context, conf *config.Config, feedUrl string, stats *fetchStats) (*rss.RSSFeed, error) {
+	// checked again here, since the feed may have been added on a machine with another config
+	args, err := execArgs(conf, feedUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, execTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = execWaitDelay
+	err = cmd.Run()
+	stats.bytes = stdout.Len()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s did not finish within %s", args[0], execTimeout)
+	}
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return nil, fmt.Errorf("Error running %s: %v", args[0], err)
+		}
+		return nil, fmt.Errorf("Error running %s: %v: %s", args[0], err, msg)
+	}
+	return parseFeed(stdout.Bytes())
+}
+
+// execArgs splits an exec: url into its program and arguments, as long as the program is
+// one the config file lets exec: feeds run. Any user of the database can add a feed and
+// every agg runs it, so other programs are refused.
+func execArgs(conf *config.Config, feedUrl string) ([]string, error) {
+	args := strings.Fields(strings.TrimPrefix(feedUrl, execPrefix))
+	if len(args) == 0 {
+		return nil, errors.New("exec: feed does not name a program")
+	}
+	if len(conf.ExecPrograms) == 0 {
+		return nil, errors.New("exec: feeds are disabled, list the programs they may run in exec_programs in the config file")
+	}
+	if !filepath.IsAbs(args[0]) {
+		return nil, fmt.Errorf("%s is not an absolute path", args[0])
+	}
+	args[0] = filepath.Clean(args[0])
+	for _, allowed := range conf.ExecPrograms {
+		if filepath.Clean(allowed) == args[0] {
+			return args, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not one of exec_programs in the config file", args[0])
+}
+
+// fetchMailbox reads every message of an mbox: or maildir: feed
+func fetchMailbox(conf *config.Config, feedUrl string, stats *fetchStats) (*rss.RSSFeed, error) {
+	path, err := mailboxPath(conf, feedUrl)
+	if err != nil {
+		return nil, err
+	}
+	// a symlink inside an allowed directory must not lead out of it
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading %s: %v", path, err)
+	}
+	if !inMailboxDirs(conf, resolved, true) {
+		return nil, fmt.Errorf("%s leads outside mailbox_dirs", path)
+	}
+
+	var feed *rss.RSSFeed
+	if strings.HasPrefix(feedUrl, maildirPrefix) {
+		feed, stats.bytes, err = mailbox.ReadMaildir(resolved)
+	} else {
+		feed, stats.bytes, err = mailbox.ReadMbox(resolved)
+	}
+	return feed, err
+}
+
+// mailboxPath returns the path an mbox: or maildir: url names, as long as it is inside
+// one of the directories the config file lets mailbox feeds read. Anything a feed reads
+// is shown to everyone who follows it, so other paths are refused.
+func mailboxPath(conf *config.Config, feedUrl string) (string, error) {
+	path := strings.TrimPrefix(strings.TrimPrefix(feedUrl, mboxPrefix), maildirPrefix)
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("%s is not an absolute path", path)
+	}
+	path = filepath.Clean(path)
+	if len(conf.MailboxDirs) == 0 {
+		return "", errors.New("mbox: and maildir: feeds are disabled, list the directories they may read in mailbox_dirs in the config file")
+	}
+	if !inMailboxDirs(conf, path, false) {
+		return "", fmt.Errorf("%s is not inside any of mailbox_dirs in the config file", path)
+	}
+	return path, nil
+}
+
+// inMailboxDirs reports whether path is inside one of the configured mailbox directories,
+// comparing against the directories' real paths when resolve is set
+func inMailboxDirs(conf *config.Config, path string, resolve bool) bool {
+	for _, dir := range conf.MailboxDirs {
+		dir = filepath.Clean(dir)
+		if resolve {
+			resolved, err := filepath.EvalSymlinks(dir)
+			if err != nil {
+				continue
+			}
+			dir = resolved
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func toSelectors(def database.FeedScraper) scrape.Selectors {
+	return scrape.Selectors{
+		Item:    def.ItemSelector,
+		Title:   def.TitleSelector,
+		Link:    def.LinkSelector,
+		Date:    def.DateSelector,
+		Summary: def.SummarySelector,
+	}
+}
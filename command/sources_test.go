@@ -0,0 +1,79 @@
+package command
+
+import (
+	"context"
+	"github.com/luckyhut/gator/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeProgram writes a shell script that prints testFeed
+func writeProgram(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	err := os.WriteFile(path, []byte("#!/bin/sh\ncat <<'EOF'\n"+testFeed+"\nEOF\n"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExecArgs(t *testing.T) {
+	conf := &config.Config{ExecPrograms: []string{"/usr/local/bin/feed"}}
+	tests := []struct {
+		url     string
+		want    string
+		wantErr string
+	}{
+		{"exec:/usr/local/bin/feed --since 7d", "/usr/local/bin/feed --since 7d", ""},
+		{"exec:/usr/local/bin/../bin/feed", "/usr/local/bin/feed", ""},
+		{"exec:/usr/bin/curl http://example.com", "", "not one of exec_programs"},
+		{"exec:feed", "", "not an absolute path"},
+		{"exec:", "", "does not name a program"},
+	}
+	for _, tt := range tests {
+		args, err := execArgs(conf, tt.url)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("execArgs(%q) error = %v, want %q", tt.url, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("execArgs(%q) error = %v", tt.url, err)
+			continue
+		}
+		if got := strings.Join(args, " "); got != tt.want {
+			t.Errorf("execArgs(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+
+	_, err := execArgs(&config.Config{}, "exec:/usr/local/bin/feed")
+	if err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("exec feeds without exec_programs: error = %v, want disabled", err)
+	}
+}
+
+func TestFetchExec(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	allowed := writeProgram(t, dir, "allowed")
+	other := writeProgram(t, dir, "other")
+	conf := &config.Config{ExecPrograms: []string{allowed}}
+
+	feed, err := fetchExec(ctx, conf, "exec:"+allowed, &fetchStats{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Channel.Item) != 2 {
+		t.Errorf("got %d items, want 2", len(feed.Channel.Item))
+	}
+
+	// a feed added on a machine that allows other programs is still refused here
+	_, err = fetchExec(ctx, conf, "exec:"+other, &fetchStats{})
+	if err == nil || !strings.Contains(err.Error(), "not one of exec_programs") {
+		t.Errorf("running a program that is not allowed: error = %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/luckyhut/gator/database"
+	"html"
+	"io"
+	"os"
+	"time"
+)
+
+func HandlerStar(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Must include a post id or url with this command")
+	}
+	ctx := context.Background()
+	postID, err := findPostID(ctx, s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	err = s.Db.StarPost(ctx, database.StarPostParams{
+		UserID:    user.ID,
+		PostID:    postID,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return errors.New("Error starring post")
+	}
+	return nil
+}
+
+func HandlerUnstar(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("Must include a post id or url with this command")
+	}
+	ctx := context.Background()
+	postID, err := findPostID(ctx, s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	n, err := s.Db.UnstarPost(ctx, database.UnstarPostParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		return errors.New("Error unstarring post")
+	}
+	if n == 0 {
+		return errors.New("That post is not starred")
+	}
+	return nil
+}
+
+// HandlerStarred lists the user's starred posts, newest star first. They stay
+// listed after the feed is unfollowed, and --format exports them.
+func HandlerStarred(s *State, cmd Command, user database.User) error {
+	flags := flag.NewFlagSet("starred", flag.ContinueOnError)
+	format := flags.String("format", "text", "text, json, or html for a bookmarks file browsers can import")
+	err := flags.Parse(cmd.Args)
+	if err != nil {
+		return err
+	}
+
+	posts, err := s.Db.GetStarredPosts(context.Background(), user.ID)
+	if err != nil {
+		return errors.New("Error getting starred posts from database")
+	}
+
+	switch *format {
+	case "text":
+		if len(posts) == 0 {
+			fmt.Println("No starred posts")
+		}
+		for _, post := range posts {
+			fmt.Println("---------------------------------------------------")
+			fmt.Printf("[%s] %s\n", shortID(post.PostID), post.Title.String)
+			fmt.Printf("%s\n", post.FeedName.String)
+			fmt.Printf("%s\n", post.Url)
+		}
+		if len(posts) > 0 {
+			fmt.Println("---------------------------------------------------")
+		}
+		return nil
+	case "json":
+		return writeStarredJSON(os.Stdout, posts)
+	case "html":
+		return writeStarredBookmarks(os.Stdout, posts)
+	}
+	return fmt.Errorf("Unknown format %s, use text, json or html", *format)
+}
+
+type starredPost struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Url         string    `json:"url"`
+	Description string    `json:"description,omitempty"`
+	PublishedAt string    `json:"published_at,omitempty"`
+	Feed        string    `json:"feed"`
+	FeedUrl     string    `json:"feed_url"`
+	StarredAt   time.Time `json:"starred_at"`
+}
+
+func writeStarredJSON(w io.Writer, posts []database.GetStarredPostsRow) error {
+	out := make([]starredPost, 0, len(posts))
+	for _, post := range posts {
+		out = append(out, starredPost{
+			ID:          post.PostID.String(),
+			Title:       post.Title.String,
+			Url:         post.Url,
+			Description: post.Description.String,
+			PublishedAt: post.PublishedAt.String,
+			Feed:        post.FeedName.String,
+			FeedUrl:     post.FeedUrl.String,
+			StarredAt:   post.StarredAt,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(out)
+}
+
+// writeStarredBookmarks writes posts in the Netscape bookmark format that browsers
+// and read-it-later services import, tagged with the name of their feed
+func writeStarredBookmarks(w io.Writer, posts []database.GetStarredPostsRow) error {
+	fmt.Fprintln(w, "<!DOCTYPE NETSCAPE-Bookmark-file-1>")
+	fmt.Fprintln(w, `<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">`)
+	fmt.Fprintln(w, "<TITLE>Starred posts</TITLE>")
+	fmt.Fprintln(w, "<H1>Starred posts</H1>")
+	fmt.Fprintln(w, "<DL><p>")
+	for _, post := range posts {
+		title := post.Title.String
+		if title == "" {
+			title = post.Url
+		}
+		fmt.Fprintf(w, "    <DT><A HREF=\"%s\" ADD_DATE=\"%d\" TAGS=\"%s\">%s</A>\n",
+			html.EscapeString(post.Url), post.StarredAt.Unix(), html.EscapeString(post.FeedName.String), html.EscapeString(title))
+	}
+	_, err := fmt.Fprintln(w, "</DL><p>")
+	return err
+}
@@ -0,0 +1,83 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/luckyhut/gator/database"
+	"strings"
+)
+
+// tags are stored lowercased so "Go" and "go" are one tag
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// findFollow returns the user's follow of the feed at url
+func findFollow(ctx context.Context, s *State, user database.User, url string) (database.FeedFollow, error) {
+	feed, err := findFeed(ctx, s, url)
+	if err != nil {
+		return database.FeedFollow{}, err
+	}
+	follow, err := s.Db.GetFeedFollow(ctx, database.GetFeedFollowParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return follow, fmt.Errorf("You don't follow %s", feed.Url.String)
+	}
+	if err != nil {
+		return follow, errors.New("Error getting feed follow from database")
+	}
+	return follow, nil
+}
+
+func HandlerTag(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) < 2 {
+		return errors.New("Usage: gator tag <url> <tag>...")
+	}
+	ctx := context.Background()
+	follow, err := findFollow(ctx, s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	for _, tag := range cmd.Args[1:] {
+		tag = normalizeTag(tag)
+		if tag == "" {
+			return errors.New("Tags can't be empty")
+		}
+		err = s.Db.TagFeedFollow(ctx, database.TagFeedFollowParams{
+			FeedFollowID: follow.ID,
+			Tag:          tag,
+		})
+		if err != nil {
+			return errors.New("Error adding tag to database")
+		}
+	}
+	return nil
+}
+
+func HandlerUntag(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) < 2 {
+		return errors.New("Usage: gator untag <url> <tag>...")
+	}
+	ctx := context.Background()
+	follow, err := findFollow(ctx, s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	for _, tag := range cmd.Args[1:] {
+		n, err := s.Db.UntagFeedFollow(ctx, database.UntagFeedFollowParams{
+			FeedFollowID: follow.ID,
+			Tag:          normalizeTag(tag),
+		})
+		if err != nil {
+			return errors.New("Error removing tag from database")
+		}
+		if n == 0 {
+			return fmt.Errorf("The feed is not tagged %s", tag)
+		}
+	}
+	return nil
+}
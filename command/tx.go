@@ -0,0 +1,40 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"github.com/luckyhut/gator/database"
+	"github.com/luckyhut/gator/metrics"
+)
+
+// txQuerier is a store that runs transactions itself, like memstore.Store
+type txQuerier interface {
+	InTx(ctx context.Context, fn func(database.Querier) error) error
+}
+
+// inTx runs fn inside one transaction, committing when fn returns nil and
+// rolling back everything it did otherwise
+func inTx(ctx context.Context, s *State, fn func(q database.Querier) error) error {
+	if store, ok := s.Db.(txQuerier); ok {
+		return store.InTx(ctx, fn)
+	}
+	if s.Conn == nil {
+		return errors.New("No database connection to start a transaction on")
+	}
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.New("Error starting transaction")
+	}
+	defer tx.Rollback()
+
+	// the same as s.Db.WithTx(tx), but keeps timing queries like main does
+	err = fn(database.New(metrics.DB(tx)))
+	if err != nil {
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return errors.New("Error committing transaction")
+	}
+	return nil
+}
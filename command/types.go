@@ -1,6 +1,7 @@
 package command
 
 import (
+	"database/sql"
 	"errors"
 	"github.com/luckyhut/gator/config"
 	"github.com/luckyhut/gator/database"
@@ -13,7 +14,22 @@ type Command struct {
 
 type State struct {
 	Config *config.Config
-	Db     *database.Queries
+	Db     database.Querier
+	Conn   *sql.DB
+}
+
+// ExitError is returned by commands that want gator to exit with a specific status code
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
 }
 
 type Commands struct {
@@ -56,7 +56,10 @@ func HandlerRegister(s *State, cmd Command) error {
 	}
 
 	// add user to database
-	s.Db.CreateUser(dbContext, params)
+	_, err = s.Db.CreateUser(dbContext, params)
+	if err != nil {
+		return errors.New("Unable to add user to database")
+	}
 	fmt.Printf("User %s was created.\n", cmd.Args[0])
 	err = HandlerLogin(s, cmd)
 	if err != nil {
@@ -69,23 +72,35 @@ func HandlerReset(s *State, cmd Command) error {
 	// needs a context
 	dbContext := context.Background()
 
-	err := s.Db.ResetUsers(dbContext)
-	if err != nil {
-		return errors.New("Unable to delete users from database")
-	}
-	fmt.Println("Users successfully deleted from database")
-
-	err = s.Db.ResetFeeds(dbContext)
-	if err != nil {
-		return errors.New("Unable to delete feeds from database")
-	}
-	fmt.Println("Feeds successfully deleted from database")
-
-	err = s.Db.ResetFeedFollow(dbContext)
+	// everything goes in one transaction, so a failure leaves the database as it was.
+	// posts don't cascade from feeds, so they go first.
+	err := inTx(dbContext, s, func(q database.Querier) error {
+		err := q.ResetPosts(dbContext)
+		if err != nil {
+			return errors.New("Unable to delete posts from database")
+		}
+		err = q.ResetAuthors(dbContext)
+		if err != nil {
+			return errors.New("Unable to delete authors from database")
+		}
+		err = q.ResetFeedFollow(dbContext)
+		if err != nil {
+			return errors.New("Unable to delete feed_follows from database")
+		}
+		err = q.ResetFeeds(dbContext)
+		if err != nil {
+			return errors.New("Unable to delete feeds from database")
+		}
+		err = q.ResetUsers(dbContext)
+		if err != nil {
+			return errors.New("Unable to delete users from database")
+		}
+		return nil
+	})
 	if err != nil {
-		return errors.New("Unable to delete feed_follows from database")
+		return err
 	}
-	fmt.Println("Feed_follows successfully deleted from database")
+	fmt.Println("Users, feeds, feed_follows and posts successfully deleted from database")
 	return nil
 }
 
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 )
 
 const configFileName = ".gatorconfig.json"
@@ -12,28 +13,73 @@ const configFileName = ".gatorconfig.json"
 type Config struct {
 	DbUrl           string `json:"db_url"`
 	CurrentUserName string `json:"current_user_name"`
+	// programs exec: feeds may run, by absolute path
+	ExecPrograms []string `json:"exec_programs,omitempty"`
+	// directories whose mbox files and Maildirs mbox: and maildir: feeds may read
+	MailboxDirs []string `json:"mailbox_dirs,omitempty"`
+	// Mastodon servers, beyond the well known ones, whose profile urls addfeed and follow rewrite
+	MastodonHosts []string `json:"mastodon_hosts,omitempty"`
+	// diagnostics go to stderr, or LogFile when set, at LogLevel in LogFormat
+	LogLevel  string `json:"log_level,omitempty"`
+	LogFormat string `json:"log_format,omitempty"`
+	LogFile   string `json:"log_file,omitempty"`
+	// unix socket agg listens on for gator ctl, defaults to ~/.gator.sock
+	ControlSocket string `json:"control_socket,omitempty"`
+	// posts older than RetentionMaxAge, like "30d", or beyond the newest RetentionMaxPosts
+	// of their feed are pruned, unless the feed sets its own limits
+	RetentionMaxAge   string `json:"retention_max_age,omitempty"`
+	RetentionMaxPosts int    `json:"retention_max_posts,omitempty"`
+	// feeds nobody has followed for OrphanGrace, like "7d", are removed with their posts
+	OrphanGrace string `json:"orphan_grace,omitempty"`
+	// users who may remove any feed
+	Admins []string `json:"admins,omitempty"`
 }
 
 func Read() Config {
-	// open ~/.gatorconfig
-	home, err := os.UserHomeDir()
+	config, err := Load()
 	if err != nil {
 		log.Fatal(err)
 	}
-	filepath := home + "/" + configFileName
-	data, err := os.ReadFile(filepath)
+	return config
+}
+
+// Load reads ~/.gatorconfig.json, returning an error instead of exiting
+func Load() (Config, error) {
+	// open ~/.gatorconfig
+	fullPath, err := getConfigFilePath()
+	if err != nil {
+		return Config{}, err
+	}
+	data, err := os.ReadFile(fullPath)
 	if err != nil {
-		log.Fatal(err)
+		return Config{}, err
 	}
 
 	// read into Config struct
 	var config Config
 	err = json.Unmarshal(data, &config)
 	if err != nil {
-		log.Fatal(err)
+		return Config{}, err
 	}
 
-	return config
+	return config, nil
+}
+
+// SocketPath returns where agg's control socket lives
+func (conf Config) SocketPath() string {
+	if conf.ControlSocket != "" {
+		return conf.ControlSocket
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "gator.sock")
+	}
+	return filepath.Join(home, ".gator.sock")
+}
+
+// IsAdmin reports whether the config file lists name as an admin
+func (conf Config) IsAdmin(name string) bool {
+	return slices.Contains(conf.Admins, name)
 }
 
 func (conf Config) SetUser(name string) error {
@@ -0,0 +1,108 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// Request is one command sent to a running agg
+type Request struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// Response is agg's answer, Error is set when the command failed
+type Response struct {
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// how long a connected client has to send its request
+const requestTimeout = 10 * time.Second
+
+// Server answers requests on a Unix socket
+type Server struct {
+	path     string
+	listener net.Listener
+}
+
+// Listen creates the socket at path and answers each request on it with handle.
+// A socket left behind by an agg that is no longer running is replaced.
+func Listen(path string, handle func(Request) Response) (*Server, error) {
+	if _, err := os.Stat(path); err == nil {
+		conn, err := net.DialTimeout("unix", path, time.Second)
+		if err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("Another agg is already listening on %s", path)
+		}
+		os.Remove(path)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("Error listening on %s: %w", path, err)
+	}
+	// only the user running agg may control it
+	err = os.Chmod(path, 0o600)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	server := &Server{path: path, listener: listener}
+	go server.serve(handle)
+	return server, nil
+}
+
+func (s *Server) serve(handle func(Request) Response) {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			var req Request
+			conn.SetReadDeadline(time.Now().Add(requestTimeout))
+			err := json.NewDecoder(conn).Decode(&req)
+			if err != nil {
+				json.NewEncoder(conn).Encode(Response{Error: "Invalid request"})
+				return
+			}
+			conn.SetReadDeadline(time.Time{})
+			json.NewEncoder(conn).Encode(handle(req))
+		}()
+	}
+}
+
+// Close stops accepting requests and removes the socket
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	os.Remove(s.path)
+	return err
+}
+
+// Call sends req to the agg listening at path and waits up to timeout for its response
+func Call(path string, req Request, timeout time.Duration) (Response, error) {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return Response{}, errors.New("No agg is running, or it was started with a different control_socket")
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	err = json.NewEncoder(conn).Encode(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("Error sending request: %w", err)
+	}
+	var resp Response
+	err = json.NewDecoder(conn).Decode(&resp)
+	if err != nil {
+		return Response{}, fmt.Errorf("Error reading response: %w", err)
+	}
+	return resp, nil
+}
@@ -13,17 +13,138 @@ import (
 	"github.com/google/uuid"
 )
 
+const claimFeed = `-- name: ClaimFeed :one
+UPDATE feeds
+SET lease_until = NOW() + $1::int * INTERVAL '1 second'
+WHERE id = $2
+AND (lease_until IS NULL OR lease_until < NOW())
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, title, description, link, image_url, language, generator, kind, lease_until, retention_max_age_seconds, retention_max_posts, orphaned_at
+`
+
+type ClaimFeedParams struct {
+	LeaseSeconds int32
+	ID           uuid.UUID
+}
+
+func (q *Queries) ClaimFeed(ctx context.Context, arg ClaimFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, claimFeed, arg.LeaseSeconds, arg.ID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Title,
+		&i.Description,
+		&i.Link,
+		&i.ImageUrl,
+		&i.Language,
+		&i.Generator,
+		&i.Kind,
+		&i.LeaseUntil,
+		&i.RetentionMaxAgeSeconds,
+		&i.RetentionMaxPosts,
+		&i.OrphanedAt,
+	)
+	return i, err
+}
+
+const claimNextFeed = `-- name: ClaimNextFeed :one
+UPDATE feeds
+SET lease_until = NOW() + $1::int * INTERVAL '1 second'
+WHERE id = (
+    SELECT id
+    FROM feeds
+    WHERE (lease_until IS NULL OR lease_until < NOW())
+    AND EXISTS (
+        SELECT 1
+        FROM feed_follows ff
+        WHERE ff.feed_id = feeds.id
+    )
+    ORDER BY last_fetched_at ASC NULLS FIRST
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, title, description, link, image_url, language, generator, kind, lease_until, retention_max_age_seconds, retention_max_posts, orphaned_at
+`
+
+func (q *Queries) ClaimNextFeed(ctx context.Context, leaseSeconds int32) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, claimNextFeed, leaseSeconds)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Title,
+		&i.Description,
+		&i.Link,
+		&i.ImageUrl,
+		&i.Language,
+		&i.Generator,
+		&i.Kind,
+		&i.LeaseUntil,
+		&i.RetentionMaxAgeSeconds,
+		&i.RetentionMaxPosts,
+		&i.OrphanedAt,
+	)
+	return i, err
+}
+
+const clearOrphanedFeeds = `-- name: ClearOrphanedFeeds :execrows
+UPDATE feeds
+SET orphaned_at = NULL
+WHERE orphaned_at IS NOT NULL
+AND EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = feeds.id
+)
+`
+
+func (q *Queries) ClearOrphanedFeeds(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, clearOrphanedFeeds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countFeeds = `-- name: CountFeeds :one
+SELECT COUNT(*)
+FROM feeds
+WHERE EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = feeds.id
+)
+`
+
+func (q *Queries) CountFeeds(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFeeds)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, kind)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, title, description, link, image_url, language, generator, kind, lease_until, retention_max_age_seconds, retention_max_posts, orphaned_at
 `
 
 type CreateFeedParams struct {
@@ -33,6 +154,7 @@ type CreateFeedParams struct {
 	Name      sql.NullString
 	Url       sql.NullString
 	UserID    uuid.NullUUID
+	Kind      string
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -43,6 +165,7 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.Kind,
 	)
 	var i Feed
 	err := row.Scan(
@@ -53,20 +176,56 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Title,
+		&i.Description,
+		&i.Link,
+		&i.ImageUrl,
+		&i.Language,
+		&i.Generator,
+		&i.Kind,
+		&i.LeaseUntil,
+		&i.RetentionMaxAgeSeconds,
+		&i.RetentionMaxPosts,
+		&i.OrphanedAt,
 	)
 	return i, err
 }
 
+const deleteFeed = `-- name: DeleteFeed :execrows
+DELETE FROM feeds
+WHERE id = $1
+AND NOT EXISTS (
+    SELECT 1
+    FROM posts p
+    WHERE p.feed_id = feeds.id
+)
+AND NOT EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = feeds.id
+)
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeed, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getAllFeeds = `-- name: GetAllFeeds :many
-SELECT f.name, f.url, u.name
+SELECT f.name, f.url, u.name, f.link, f.description
 FROM feeds f
 JOIN users u ON f.user_id = u.id
 `
 
 type GetAllFeedsRow struct {
-	Name   sql.NullString
-	Url    sql.NullString
-	Name_2 string
+	Name        sql.NullString
+	Url         sql.NullString
+	Name_2      string
+	Link        sql.NullString
+	Description sql.NullString
 }
 
 func (q *Queries) GetAllFeeds(ctx context.Context) ([]GetAllFeedsRow, error) {
@@ -78,7 +237,13 @@ func (q *Queries) GetAllFeeds(ctx context.Context) ([]GetAllFeedsRow, error) {
 	var items []GetAllFeedsRow
 	for rows.Next() {
 		var i GetAllFeedsRow
-		if err := rows.Scan(&i.Name, &i.Url, &i.Name_2); err != nil {
+		if err := rows.Scan(
+			&i.Name,
+			&i.Url,
+			&i.Name_2,
+			&i.Link,
+			&i.Description,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -105,15 +270,14 @@ func (q *Queries) GetFeed(ctx context.Context, url sql.NullString) (uuid.UUID, e
 	return id, err
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+const getFeedByUrl = `-- name: GetFeedByUrl :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, title, description, link, image_url, language, generator, kind, lease_until, retention_max_age_seconds, retention_max_posts, orphaned_at
 FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1
+WHERE url = $1
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
+func (q *Queries) GetFeedByUrl(ctx context.Context, url sql.NullString) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByUrl, url)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -123,20 +287,174 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Title,
+		&i.Description,
+		&i.Link,
+		&i.ImageUrl,
+		&i.Language,
+		&i.Generator,
+		&i.Kind,
+		&i.LeaseUntil,
+		&i.RetentionMaxAgeSeconds,
+		&i.RetentionMaxPosts,
+		&i.OrphanedAt,
 	)
 	return i, err
 }
 
+const getFeedQueueLag = `-- name: GetFeedQueueLag :one
+SELECT EXTRACT(EPOCH FROM $1::timestamp - COALESCE(MIN(COALESCE(last_fetched_at, created_at)), $1::timestamp))::float8 AS lag_seconds
+FROM feeds
+WHERE EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = feeds.id
+)
+`
+
+func (q *Queries) GetFeedQueueLag(ctx context.Context, now time.Time) (float64, error) {
+	row := q.db.QueryRowContext(ctx, getFeedQueueLag, now)
+	var lag_seconds float64
+	err := row.Scan(&lag_seconds)
+	return lag_seconds, err
+}
+
+const getFeedsToFetch = `-- name: GetFeedsToFetch :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, title, description, link, image_url, language, generator, kind, lease_until, retention_max_age_seconds, retention_max_posts, orphaned_at
+FROM feeds
+WHERE (last_fetched_at IS NULL OR last_fetched_at < $1::timestamp)
+AND EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = feeds.id
+)
+ORDER BY last_fetched_at ASC NULLS FIRST
+`
+
+func (q *Queries) GetFeedsToFetch(ctx context.Context, dueBefore time.Time) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsToFetch, dueBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Title,
+			&i.Description,
+			&i.Link,
+			&i.ImageUrl,
+			&i.Language,
+			&i.Generator,
+			&i.Kind,
+			&i.LeaseUntil,
+			&i.RetentionMaxAgeSeconds,
+			&i.RetentionMaxPosts,
+			&i.OrphanedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrphanedFeeds = `-- name: GetOrphanedFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, title, description, link, image_url, language, generator, kind, lease_until, retention_max_age_seconds, retention_max_posts, orphaned_at
+FROM feeds
+WHERE orphaned_at < $1::timestamp
+AND NOT EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = feeds.id
+)
+ORDER BY orphaned_at
+`
+
+func (q *Queries) GetOrphanedFeeds(ctx context.Context, orphanedBefore time.Time) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getOrphanedFeeds, orphanedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Title,
+			&i.Description,
+			&i.Link,
+			&i.ImageUrl,
+			&i.Language,
+			&i.Generator,
+			&i.Kind,
+			&i.LeaseUntil,
+			&i.RetentionMaxAgeSeconds,
+			&i.RetentionMaxPosts,
+			&i.OrphanedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockFeed = `-- name: LockFeed :exec
+SELECT id
+FROM feeds
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, lockFeed, id)
+	return err
+}
+
 const markFeedFetched = `-- name: MarkFeedFetched :one
 UPDATE feeds
-SET last_fetched_at = NOW(),
-updated_at = NOW()
-WHERE id = $1
-returning id, created_at, updated_at, name, url, user_id, last_fetched_at
+SET last_fetched_at = $1::timestamp,
+lease_until = NULL,
+updated_at = $1::timestamp
+WHERE id = $2
+returning id, created_at, updated_at, name, url, user_id, last_fetched_at, title, description, link, image_url, language, generator, kind, lease_until, retention_max_age_seconds, retention_max_posts, orphaned_at
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, markFeedFetched, id)
+type MarkFeedFetchedParams struct {
+	FetchedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, markFeedFetched, arg.FetchedAt, arg.ID)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -146,10 +464,60 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, erro
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Title,
+		&i.Description,
+		&i.Link,
+		&i.ImageUrl,
+		&i.Language,
+		&i.Generator,
+		&i.Kind,
+		&i.LeaseUntil,
+		&i.RetentionMaxAgeSeconds,
+		&i.RetentionMaxPosts,
+		&i.OrphanedAt,
 	)
 	return i, err
 }
 
+const markOrphanedFeeds = `-- name: MarkOrphanedFeeds :execrows
+UPDATE feeds
+SET orphaned_at = $1
+WHERE orphaned_at IS NULL
+AND NOT EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = feeds.id
+)
+`
+
+func (q *Queries) MarkOrphanedFeeds(ctx context.Context, orphanedAt sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markOrphanedFeeds, orphanedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const notifyFeedAdded = `-- name: NotifyFeedAdded :exec
+SELECT pg_notify('gator_feeds', $1::text)
+`
+
+func (q *Queries) NotifyFeedAdded(ctx context.Context, url string) error {
+	_, err := q.db.ExecContext(ctx, notifyFeedAdded, url)
+	return err
+}
+
+const releaseFeed = `-- name: ReleaseFeed :exec
+UPDATE feeds
+SET lease_until = NULL
+WHERE id = $1
+`
+
+func (q *Queries) ReleaseFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, releaseFeed, id)
+	return err
+}
+
 const resetFeeds = `-- name: ResetFeeds :exec
 DELETE FROM feeds
 `
@@ -158,3 +526,58 @@ func (q *Queries) ResetFeeds(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetFeeds)
 	return err
 }
+
+const setFeedRetention = `-- name: SetFeedRetention :exec
+UPDATE feeds
+SET retention_max_age_seconds = $2,
+retention_max_posts = $3,
+updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedRetentionParams struct {
+	ID                     uuid.UUID
+	RetentionMaxAgeSeconds sql.NullInt64
+	RetentionMaxPosts      sql.NullInt32
+}
+
+func (q *Queries) SetFeedRetention(ctx context.Context, arg SetFeedRetentionParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedRetention, arg.ID, arg.RetentionMaxAgeSeconds, arg.RetentionMaxPosts)
+	return err
+}
+
+const updateFeedMetadata = `-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET title = $2,
+description = $3,
+link = $4,
+image_url = $5,
+language = $6,
+generator = $7,
+name = COALESCE(NULLIF(name, ''), $2),
+updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedMetadataParams struct {
+	ID          uuid.UUID
+	Title       sql.NullString
+	Description sql.NullString
+	Link        sql.NullString
+	ImageUrl    sql.NullString
+	Language    sql.NullString
+	Generator   sql.NullString
+}
+
+func (q *Queries) UpdateFeedMetadata(ctx context.Context, arg UpdateFeedMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedMetadata,
+		arg.ID,
+		arg.Title,
+		arg.Description,
+		arg.Link,
+		arg.ImageUrl,
+		arg.Language,
+		arg.Generator,
+	)
+	return err
+}
@@ -17,10 +17,10 @@ const createFeedFollow = `-- name: CreateFeedFollow :exec
 WITH inserted_feed_follow AS (
     INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
         VALUES ($1, $2, $3, $4, $5)
-    RETURNING id, created_at, updated_at, user_id, feed_id
+    RETURNING id, created_at, updated_at, user_id, feed_id, title
 )
 select
-    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id,
+    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.title,
     u.name AS feed_name,
     f.name AS user_name
 from inserted_feed_follow
@@ -49,19 +49,73 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 	return err
 }
 
+const deleteFeedFollows = `-- name: DeleteFeedFollows :execrows
+DELETE FROM feed_follows
+WHERE feed_id = $1
+`
+
+func (q *Queries) DeleteFeedFollows(ctx context.Context, feedID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedFollows, feedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFeedFollow = `-- name: GetFeedFollow :one
+SELECT id, created_at, updated_at, user_id, feed_id, title FROM feed_follows
+WHERE user_id = $1
+AND feed_id = $2
+`
+
+type GetFeedFollowParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+}
+
+func (q *Queries) GetFeedFollow(ctx context.Context, arg GetFeedFollowParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollow, arg.UserID, arg.FeedID)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.Title,
+	)
+	return i, err
+}
+
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-select f.name as feed_name, u.name as user_name
+select coalesce(feed_follows.title, f.name) as feed_name, u.name as user_name, t.tag,
+    (
+        select count(*)
+        from posts p
+        where p.feed_id = f.id
+        and not exists (
+            select 1
+            from post_reads pr
+            where pr.post_id = p.id
+            and pr.user_id = feed_follows.user_id
+        )
+    ) as unread_count
 from feed_follows
 inner join
     users u on feed_follows.user_id = u.id
 inner join
     feeds f on feed_follows.feed_id = f.id
+left join
+    feed_follow_tags t on t.feed_follow_id = feed_follows.id
 where feed_follows.user_id = $1
+order by t.tag nulls last, feed_name
 `
 
 type GetFeedFollowsForUserRow struct {
-	FeedName sql.NullString
-	UserName string
+	FeedName    sql.NullString
+	UserName    string
+	Tag         sql.NullString
+	UnreadCount int64
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
@@ -73,7 +127,12 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	var items []GetFeedFollowsForUserRow
 	for rows.Next() {
 		var i GetFeedFollowsForUserRow
-		if err := rows.Scan(&i.FeedName, &i.UserName); err != nil {
+		if err := rows.Scan(
+			&i.FeedName,
+			&i.UserName,
+			&i.Tag,
+			&i.UnreadCount,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -87,6 +146,23 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	return items, nil
 }
 
+const renameFeedFollow = `-- name: RenameFeedFollow :exec
+UPDATE feed_follows
+SET title = $2, updated_at = $3
+WHERE id = $1
+`
+
+type RenameFeedFollowParams struct {
+	ID        uuid.UUID
+	Title     sql.NullString
+	UpdatedAt time.Time
+}
+
+func (q *Queries) RenameFeedFollow(ctx context.Context, arg RenameFeedFollowParams) error {
+	_, err := q.db.ExecContext(ctx, renameFeedFollow, arg.ID, arg.Title, arg.UpdatedAt)
+	return err
+}
+
 const resetFeedFollow = `-- name: ResetFeedFollow :exec
 DELETE FROM feed_follows
 `
@@ -11,14 +11,44 @@ import (
 	"github.com/google/uuid"
 )
 
+type Author struct {
+	ID         uuid.UUID
+	Name       string
+	Normalized string
+}
+
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          sql.NullString
-	Url           sql.NullString
-	UserID        uuid.NullUUID
-	LastFetchedAt sql.NullTime
+	ID                     uuid.UUID
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+	Name                   sql.NullString
+	Url                    sql.NullString
+	UserID                 uuid.NullUUID
+	LastFetchedAt          sql.NullTime
+	Title                  sql.NullString
+	Description            sql.NullString
+	Link                   sql.NullString
+	ImageUrl               sql.NullString
+	Language               sql.NullString
+	Generator              sql.NullString
+	Kind                   string
+	LeaseUntil             sql.NullTime
+	RetentionMaxAgeSeconds sql.NullInt64
+	RetentionMaxPosts      sql.NullInt32
+	OrphanedAt             sql.NullTime
+}
+
+type FeedFetch struct {
+	ID           uuid.UUID
+	FeedID       uuid.UUID
+	StartedAt    time.Time
+	DurationMs   int64
+	HttpStatus   sql.NullInt32
+	Bytes        int64
+	NewItems     int32
+	UpdatedItems int32
+	SkippedItems int32
+	Error        sql.NullString
 }
 
 type FeedFollow struct {
@@ -27,6 +57,33 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Title     sql.NullString
+}
+
+type FeedFollowTag struct {
+	FeedFollowID uuid.UUID
+	Tag          string
+}
+
+type FeedRule struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	FeedID      uuid.UUID
+	UserID      uuid.UUID
+	Position    int32
+	Action      string
+	Field       string
+	Pattern     string
+	Replacement string
+}
+
+type FeedScraper struct {
+	FeedID          uuid.UUID
+	ItemSelector    string
+	TitleSelector   string
+	LinkSelector    string
+	DateSelector    string
+	SummarySelector string
 }
 
 type Post struct {
@@ -38,6 +95,30 @@ type Post struct {
 	Description sql.NullString
 	PublishedAt sql.NullString
 	FeedID      uuid.UUID
+	Guid        sql.NullString
+	Search      interface{}
+}
+
+type PostAuthor struct {
+	PostID   uuid.UUID
+	AuthorID uuid.UUID
+}
+
+type PostCategory struct {
+	PostID   uuid.UUID
+	Category string
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostStar struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	CreatedAt time.Time
 }
 
 type User struct {
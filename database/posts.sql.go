@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createPost = `-- name: CreatePost :exec
@@ -52,23 +53,173 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) error {
 	return err
 }
 
+const deleteFeedPosts = `-- name: DeleteFeedPosts :execrows
+DELETE FROM posts p
+WHERE p.feed_id = $1
+AND NOT EXISTS (
+    SELECT 1
+    FROM post_stars ps
+    WHERE ps.post_id = p.id
+)
+AND NOT EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = p.feed_id
+)
+`
+
+func (q *Queries) DeleteFeedPosts(ctx context.Context, feedID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedPosts, feedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deletePosts = `-- name: DeletePosts :execrows
+DELETE FROM posts p
+WHERE p.id = ANY($1::uuid[])
+AND NOT EXISTS (
+    SELECT 1
+    FROM post_stars ps
+    WHERE ps.post_id = p.id
+)
+`
+
+func (q *Queries) DeletePosts(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePosts, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getPostByUrl = `-- name: GetPostByUrl :one
+SELECT id
+FROM posts
+WHERE url = $1
+`
+
+func (q *Queries) GetPostByUrl(ctx context.Context, url string) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getPostByUrl, url)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getPostsByIdPrefix = `-- name: GetPostsByIdPrefix :many
+SELECT id
+FROM posts
+WHERE id::text LIKE $1::text || '%'
+LIMIT 2
+`
+
+func (q *Queries) GetPostsByIdPrefix(ctx context.Context, prefix string) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsByIdPrefix, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, p.guid,
+    COALESCE(ff.title, f.name) AS feed_name
 FROM posts p
 INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+INNER JOIN feeds f ON p.feed_id = f.id
 WHERE ff.user_id = $1
+AND (
+    $2::text IS NULL
+    OR EXISTS (
+        SELECT 1
+        FROM post_categories pc
+        WHERE pc.post_id = p.id
+        AND pc.category = $2
+    )
+)
+AND (
+    $3::text IS NULL
+    OR EXISTS (
+        SELECT 1
+        FROM post_authors pa
+        INNER JOIN authors a ON pa.author_id = a.id
+        WHERE pa.post_id = p.id
+        AND a.normalized = $3
+    )
+)
+AND (
+    NOT $4::boolean
+    OR NOT EXISTS (
+        SELECT 1
+        FROM post_reads pr
+        WHERE pr.post_id = p.id
+        AND pr.user_id = $1
+    )
+)
+AND (
+    $5::text IS NULL
+    OR EXISTS (
+        SELECT 1
+        FROM feed_follow_tags t
+        WHERE t.feed_follow_id = ff.id
+        AND t.tag = $5
+    )
+)
 ORDER BY p.published_at DESC
 `
 
-func (q *Queries) GetPostsForUser(ctx context.Context, userID uuid.UUID) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, userID)
+type GetPostsForUserParams struct {
+	UserID     uuid.UUID
+	Category   sql.NullString
+	Author     sql.NullString
+	UnreadOnly bool
+	Tag        sql.NullString
+}
+
+type GetPostsForUserRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       sql.NullString
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullString
+	FeedID      uuid.UUID
+	Guid        sql.NullString
+	FeedName    sql.NullString
+}
+
+func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.Category,
+		arg.Author,
+		arg.UnreadOnly,
+		arg.Tag,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Post
+	var items []GetPostsForUserRow
 	for rows.Next() {
-		var i Post
+		var i GetPostsForUserRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.CreatedAt,
@@ -78,6 +229,8 @@ func (q *Queries) GetPostsForUser(ctx context.Context, userID uuid.UUID) ([]Post
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Guid,
+			&i.FeedName,
 		); err != nil {
 			return nil, err
 		}
@@ -91,3 +244,212 @@ func (q *Queries) GetPostsForUser(ctx context.Context, userID uuid.UUID) ([]Post
 	}
 	return items, nil
 }
+
+const getPostsToPrune = `-- name: GetPostsToPrune :many
+SELECT id, feed_id, feed_url
+FROM (
+    SELECT p.id,
+        p.feed_id,
+        f.url AS feed_url,
+        p.created_at,
+        COALESCE(f.retention_max_age_seconds, $1::bigint) AS max_age_seconds,
+        COALESCE(f.retention_max_posts, $2::int) AS max_posts,
+        ROW_NUMBER() OVER (PARTITION BY p.feed_id ORDER BY p.created_at DESC, p.id) AS position
+    FROM posts p
+    INNER JOIN feeds f ON p.feed_id = f.id
+    WHERE NOT EXISTS (
+        SELECT 1
+        FROM post_stars ps
+        WHERE ps.post_id = p.id
+    )
+) ranked
+WHERE (max_age_seconds IS NOT NULL AND created_at < $3::timestamp - max_age_seconds * INTERVAL '1 second')
+OR (max_posts IS NOT NULL AND position > max_posts)
+ORDER BY feed_url, created_at
+`
+
+type GetPostsToPruneParams struct {
+	DefaultMaxAgeSeconds sql.NullInt64
+	DefaultMaxPosts      sql.NullInt32
+	Now                  time.Time
+}
+
+type GetPostsToPruneRow struct {
+	ID      uuid.UUID
+	FeedID  uuid.UUID
+	FeedUrl sql.NullString
+}
+
+func (q *Queries) GetPostsToPrune(ctx context.Context, arg GetPostsToPruneParams) ([]GetPostsToPruneRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsToPrune, arg.DefaultMaxAgeSeconds, arg.DefaultMaxPosts, arg.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsToPruneRow
+	for rows.Next() {
+		var i GetPostsToPruneRow
+		if err := rows.Scan(&i.ID, &i.FeedID, &i.FeedUrl); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resetPosts = `-- name: ResetPosts :exec
+DELETE FROM posts
+`
+
+func (q *Queries) ResetPosts(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, resetPosts)
+	return err
+}
+
+const searchPosts = `-- name: SearchPosts :many
+SELECT m.id,
+    m.title,
+    m.url,
+    m.published_at,
+    m.feed_name,
+    m.rank,
+    ts_headline('english', COALESCE(m.title, ''), m.query, 'HighlightAll=true, StartSel=**, StopSel=**') AS title_headline,
+    ts_headline('english', COALESCE(m.description, ''), m.query, 'MaxFragments=2, MaxWords=20, MinWords=8, StartSel=**, StopSel=**') AS headline
+FROM (
+    SELECT p.id,
+        p.title,
+        p.url,
+        p.description,
+        p.published_at,
+        COALESCE(ff.title, f.name) AS feed_name,
+        q.query,
+        ts_rank_cd(p.search, q.query)::float8 AS rank
+    FROM posts p
+    INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+    INNER JOIN feeds f ON p.feed_id = f.id
+    CROSS JOIN to_tsquery('english', $1) AS q(query)
+    WHERE ff.user_id = $2
+    AND p.search @@ q.query
+    ORDER BY rank DESC, p.published_at DESC
+    LIMIT $3
+) m
+ORDER BY m.rank DESC, m.published_at DESC
+`
+
+type SearchPostsParams struct {
+	Query  string
+	UserID uuid.UUID
+	Limit  int32
+}
+
+type SearchPostsRow struct {
+	ID            uuid.UUID
+	Title         sql.NullString
+	Url           string
+	PublishedAt   sql.NullString
+	FeedName      sql.NullString
+	Rank          float64
+	TitleHeadline string
+	Headline      string
+}
+
+func (q *Queries) SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPosts, arg.Query, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsRow
+	for rows.Next() {
+		var i SearchPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Url,
+			&i.PublishedAt,
+			&i.FeedName,
+			&i.Rank,
+			&i.TitleHeadline,
+			&i.Headline,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertPost = `-- name: UpsertPost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+ON CONFLICT (url) DO UPDATE
+SET title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    published_at = EXCLUDED.published_at,
+    guid = EXCLUDED.guid,
+    updated_at = EXCLUDED.updated_at
+WHERE posts.feed_id = EXCLUDED.feed_id
+AND (
+    posts.title IS DISTINCT FROM EXCLUDED.title
+    OR posts.description IS DISTINCT FROM EXCLUDED.description
+    OR posts.published_at IS DISTINCT FROM EXCLUDED.published_at
+    OR posts.guid IS DISTINCT FROM EXCLUDED.guid
+)
+RETURNING id, (xmax = 0)::boolean AS inserted
+`
+
+type UpsertPostParams struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       sql.NullString
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullString
+	FeedID      uuid.UUID
+	Guid        sql.NullString
+}
+
+type UpsertPostRow struct {
+	ID       uuid.UUID
+	Inserted bool
+}
+
+func (q *Queries) UpsertPost(ctx context.Context, arg UpsertPostParams) (UpsertPostRow, error) {
+	row := q.db.QueryRowContext(ctx, upsertPost,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+		arg.Guid,
+	)
+	var i UpsertPostRow
+	err := row.Scan(&i.ID, &i.Inserted)
+	return i, err
+}
@@ -0,0 +1,210 @@
+package feedurl
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Rewriter turns the url of a known kind of page into the url of its feed.
+// Handles reports whether a url is on the rewriter's site at all, and Rewrite
+// reports false for pages on that site it has no feed for.
+type Rewriter struct {
+	Name    string
+	Handles func(u *url.URL) bool
+	Rewrite func(u *url.URL) (string, bool)
+}
+
+// Rewriters are tried in order and the first one that handles a url decides it,
+// so a page a site's rewriter declines is never passed on to another rewriter.
+// Add to it to support more sites; rewriters must only do string work, never fetch.
+var Rewriters = []Rewriter{
+	{Name: "YouTube", Handles: onHosts("youtube.com"), Rewrite: youtube},
+	{Name: "Reddit", Handles: onHosts("reddit.com", "old.reddit.com"), Rewrite: reddit},
+	{Name: "GitHub", Handles: onHosts("github.com"), Rewrite: github},
+	{Name: "Substack", Handles: onSubdomains("substack.com"), Rewrite: substack},
+	{Name: "Medium", Handles: onHosts("medium.com"), Rewrite: medium},
+	{Name: "Medium", Handles: onSubdomains("medium.com"), Rewrite: medium},
+	{Name: "Mastodon", Handles: isMastodon, Rewrite: mastodon},
+}
+
+// MastodonHosts are the servers whose /@user profile pages are rewritten to their
+// feeds. Other hosts use the same form for pages without one, so the rewriting is
+// limited to servers known to run Mastodon or something compatible, plus any host
+// named like one. Add self-hosted instances to it.
+var MastodonHosts = []string{
+	"mastodon.social",
+	"mastodon.online",
+	"mas.to",
+	"mstdn.social",
+	"fosstodon.org",
+	"hachyderm.io",
+	"infosec.exchange",
+	"techhub.social",
+	"universeodon.com",
+	"mastodon.world",
+	"social.vivaldi.net",
+	"ioc.exchange",
+	"chaos.social",
+	"floss.social",
+	"indieweb.social",
+}
+
+// Rewrite returns the feed url for a page url and the name of the rewriter
+// that matched. Urls no rewriter knows are returned unchanged with ok false.
+func Rewrite(raw string) (feedURL string, name string, ok bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return raw, "", false
+	}
+	for _, r := range Rewriters {
+		if !r.Handles(u) {
+			continue
+		}
+		if rewritten, ok := r.Rewrite(u); ok {
+			return rewritten, r.Name, true
+		}
+		return raw, "", false
+	}
+	return raw, "", false
+}
+
+// segments splits a url path into its non-empty parts
+func segments(u *url.URL) []string {
+	var parts []string
+	for _, p := range strings.Split(u.Path, "/") {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+// host returns the lowercased host without port or a leading www. or m.
+func host(u *url.URL) string {
+	h := strings.ToLower(u.Hostname())
+	h = strings.TrimPrefix(h, "www.")
+	return strings.TrimPrefix(h, "m.")
+}
+
+// onHosts handles urls on exactly the given hosts
+func onHosts(hosts ...string) func(u *url.URL) bool {
+	return func(u *url.URL) bool {
+		return slices.Contains(hosts, host(u))
+	}
+}
+
+// onSubdomains handles urls on any subdomain of domain
+func onSubdomains(domain string) func(u *url.URL) bool {
+	return func(u *url.URL) bool {
+		return strings.HasSuffix(host(u), "."+domain)
+	}
+}
+
+func isMastodon(u *url.URL) bool {
+	h := host(u)
+	if slices.Contains(MastodonHosts, h) {
+		return true
+	}
+	for _, label := range strings.Split(h, ".") {
+		if strings.Contains(label, "mastodon") || label == "mstdn" {
+			return true
+		}
+	}
+	return false
+}
+
+// youtube.com/channel/<id> and youtube.com/playlist?list=<id>. Handle urls like
+// youtube.com/@name have no feed url of their own without looking up the channel.
+func youtube(u *url.URL) (string, bool) {
+	parts := segments(u)
+	if len(parts) >= 2 && parts[0] == "channel" {
+		return "https://www.youtube.com/feeds/videos.xml?channel_id=" + url.QueryEscape(parts[1]), true
+	}
+	if len(parts) == 1 && parts[0] == "playlist" && u.Query().Get("list") != "" {
+		return "https://www.youtube.com/feeds/videos.xml?playlist_id=" + url.QueryEscape(u.Query().Get("list")), true
+	}
+	return "", false
+}
+
+// reddit.com/r/<subreddit> and reddit.com/user/<name>
+func reddit(u *url.URL) (string, bool) {
+	parts := segments(u)
+	if len(parts) < 2 || (parts[0] != "r" && parts[0] != "user" && parts[0] != "u") {
+		return "", false
+	}
+	if strings.HasSuffix(u.Path, ".rss") {
+		return "", false
+	}
+	if parts[0] == "u" {
+		parts[0] = "user"
+	}
+	return "https://www.reddit.com/" + parts[0] + "/" + parts[1] + "/.rss", true
+}
+
+// top level github.com pages that are not users or organizations
+var githubReserved = []string{
+	"about", "apps", "codespaces", "collections", "contact", "customer-stories", "dashboard",
+	"enterprise", "events", "explore", "features", "issues", "join", "login", "logout",
+	"marketplace", "new", "notifications", "orgs", "organizations", "pricing", "pulls",
+	"search", "security", "settings", "site", "sponsors", "team", "topics", "trending",
+}
+
+// github.com/<owner> for activity, github.com/<owner>/<repo> for releases, and
+// github.com/<owner>/<repo>/commits[/<branch>] and /tags
+func github(u *url.URL) (string, bool) {
+	parts := segments(u)
+	if len(parts) == 0 || slices.Contains(githubReserved, strings.ToLower(parts[0])) || strings.HasSuffix(u.Path, ".atom") {
+		return "", false
+	}
+	repo := ""
+	if len(parts) >= 2 {
+		repo = "https://github.com/" + parts[0] + "/" + strings.TrimSuffix(parts[1], ".git")
+	}
+	switch {
+	case len(parts) == 1:
+		return "https://github.com/" + parts[0] + ".atom", true
+	case len(parts) == 2:
+		return repo + "/releases.atom", true
+	case len(parts) == 3 && parts[2] == "releases":
+		return repo + "/releases.atom", true
+	case len(parts) == 3 && parts[2] == "commits":
+		return repo + "/commits.atom", true
+	case len(parts) >= 4 && parts[2] == "commits":
+		return repo + "/commits/" + strings.Join(parts[3:], "/") + ".atom", true
+	case len(parts) == 3 && parts[2] == "tags":
+		return repo + "/tags.atom", true
+	}
+	return "", false
+}
+
+// <name>.substack.com
+func substack(u *url.URL) (string, bool) {
+	parts := segments(u)
+	if len(parts) > 0 && parts[0] == "feed" {
+		return "", false
+	}
+	return "https://" + host(u) + "/feed", true
+}
+
+// medium.com/@<user> and <name>.medium.com
+func medium(u *url.URL) (string, bool) {
+	h := host(u)
+	parts := segments(u)
+	switch {
+	case h == "medium.com" && len(parts) >= 1 && strings.HasPrefix(parts[0], "@"):
+		return "https://medium.com/feed/" + parts[0], true
+	case strings.HasSuffix(h, ".medium.com") && (len(parts) == 0 || parts[0] != "feed"):
+		return "https://" + h + "/feed", true
+	}
+	return "", false
+}
+
+// <instance>/@<user>, the profile form used by Mastodon and compatible servers
+func mastodon(u *url.URL) (string, bool) {
+	parts := segments(u)
+	if len(parts) != 1 || !strings.HasPrefix(parts[0], "@") || len(parts[0]) < 2 || strings.HasSuffix(parts[0], ".rss") {
+		return "", false
+	}
+	return "https://" + u.Host + "/" + parts[0] + ".rss", true
+}
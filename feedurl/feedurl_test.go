@@ -0,0 +1,67 @@
+package feedurl
+
+import "testing"
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+		ok   bool
+	}{
+		{"youtube channel", "https://www.youtube.com/channel/UC123", "https://www.youtube.com/feeds/videos.xml?channel_id=UC123", true},
+		{"youtube playlist", "https://youtube.com/playlist?list=PL9", "https://www.youtube.com/feeds/videos.xml?playlist_id=PL9", true},
+		{"youtube handle", "https://www.youtube.com/@handle", "https://www.youtube.com/@handle", false},
+		{"youtube video", "https://m.youtube.com/watch?v=abc", "https://m.youtube.com/watch?v=abc", false},
+		{"subreddit", "https://old.reddit.com/r/golang/", "https://www.reddit.com/r/golang/.rss", true},
+		{"reddit user", "https://reddit.com/u/someone", "https://www.reddit.com/user/someone/.rss", true},
+		{"reddit feed", "https://www.reddit.com/r/golang/.rss", "https://www.reddit.com/r/golang/.rss", false},
+		{"github user", "https://github.com/golang", "https://github.com/golang.atom", true},
+		{"github repo", "https://github.com/golang/go", "https://github.com/golang/go/releases.atom", true},
+		{"github repo .git", "https://github.com/golang/go.git", "https://github.com/golang/go/releases.atom", true},
+		{"github releases", "https://github.com/golang/go/releases", "https://github.com/golang/go/releases.atom", true},
+		{"github commits", "https://github.com/golang/go/commits", "https://github.com/golang/go/commits.atom", true},
+		{"github branch commits", "https://github.com/golang/go/commits/release-branch/go1.24", "https://github.com/golang/go/commits/release-branch/go1.24.atom", true},
+		{"github tags", "https://github.com/golang/go/tags", "https://github.com/golang/go/tags.atom", true},
+		{"github issues", "https://github.com/golang/go/issues", "https://github.com/golang/go/issues", false},
+		{"github issue", "https://github.com/golang/go/issues/1", "https://github.com/golang/go/issues/1", false},
+		{"github features", "https://github.com/features", "https://github.com/features", false},
+		{"github settings", "https://github.com/settings/profile", "https://github.com/settings/profile", false},
+		{"github atom", "https://github.com/golang/go/releases.atom", "https://github.com/golang/go/releases.atom", false},
+		{"substack", "https://someone.substack.com/p/a-post", "https://someone.substack.com/feed", true},
+		{"substack feed", "https://someone.substack.com/feed", "https://someone.substack.com/feed", false},
+		{"medium user", "https://medium.com/@someone", "https://medium.com/feed/@someone", true},
+		{"medium publication", "https://team.medium.com/", "https://team.medium.com/feed", true},
+		{"mastodon", "https://mastodon.social/@Gargron", "https://mastodon.social/@Gargron.rss", true},
+		{"mastodon named host", "https://mastodon.example.org/@someone", "https://mastodon.example.org/@someone.rss", true},
+		{"mastodon feed", "https://fosstodon.org/@someone.rss", "https://fosstodon.org/@someone.rss", false},
+		{"mastodon post", "https://fosstodon.org/@someone/1234", "https://fosstodon.org/@someone/1234", false},
+		{"twitter", "https://twitter.com/@someone", "https://twitter.com/@someone", false},
+		{"tiktok", "https://www.tiktok.com/@someone", "https://www.tiktok.com/@someone", false},
+		{"unknown site", "https://example.com/@someone", "https://example.com/@someone", false},
+		{"feed url", "https://blog.golang.org/feed.atom", "https://blog.golang.org/feed.atom", false},
+		{"not http", "exec:/usr/bin/report", "exec:/usr/bin/report", false},
+		{"mailbox", "mbox:/home/me/mail.mbox", "mbox:/home/me/mail.mbox", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, ok := Rewrite(tt.in)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Rewrite(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRewriteExtraMastodonHost(t *testing.T) {
+	in := "https://social.example.com/@someone"
+	if _, _, ok := Rewrite(in); ok {
+		t.Fatalf("Rewrite(%q) rewrote an unknown host", in)
+	}
+	defer func(hosts []string) { MastodonHosts = hosts }(MastodonHosts)
+	MastodonHosts = append(MastodonHosts, "social.example.com")
+	got, name, ok := Rewrite(in)
+	if !ok || name != "Mastodon" || got != "https://social.example.com/@someone.rss" {
+		t.Errorf("Rewrite(%q) = %q, %q, %v", in, got, name, ok)
+	}
+}
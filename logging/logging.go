@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// the log file opened by the last Setup, closed when Setup is called again
+var current io.Closer
+
+// Setup makes the default slog logger write at level in format ("text" or
+// "json") to file, or to stderr when file is empty. Empty values fall back to
+// info and text. It can be called again to reconfigure logging.
+func Setup(level, format, file string) error {
+	var lvl slog.Level
+	switch strings.ToLower(level) {
+	case "", "info":
+		lvl = slog.LevelInfo
+	case "debug":
+		lvl = slog.LevelDebug
+	case "warn", "warning":
+		lvl = slog.LevelWarn
+	case "error":
+		lvl = slog.LevelError
+	default:
+		return fmt.Errorf("Unknown log level %s, must be debug, info, warn or error", level)
+	}
+
+	var out io.Writer = os.Stderr
+	var closer io.Closer
+	if file != "" {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("Error opening log file: %w", err)
+		}
+		out = f
+		closer = f
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", "text":
+		handler = slog.NewTextHandler(out, opts)
+	case "json":
+		handler = slog.NewJSONHandler(out, opts)
+	default:
+		if closer != nil {
+			closer.Close()
+		}
+		return fmt.Errorf("Unknown log format %s, must be text or json", format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	if current != nil {
+		current.Close()
+	}
+	current = closer
+	return nil
+}
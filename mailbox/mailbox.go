@@ -0,0 +1,225 @@
+package mailbox
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/luckyhut/gator/rss"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// matches the ">From " quoting mbox writers add to body lines that start with "From "
+var quotedFrom = regexp.MustCompile(`^>+From `)
+
+var headerDecoder = new(mime.WordDecoder)
+
+// ReadMbox turns every message in an mbox file into a feed item.
+// It also returns the number of bytes read.
+func ReadMbox(path string) (*rss.RSSFeed, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Error reading mbox: %w", err)
+	}
+
+	feed := newFeed(path)
+	for _, raw := range splitMbox(data) {
+		item, err := parseMessage(raw)
+		if err != nil {
+			continue
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+	return feed, len(data), nil
+}
+
+// ReadMaildir turns every message in the cur and new folders of a Maildir into a feed item.
+// It also returns the number of bytes read.
+func ReadMaildir(dir string) (*rss.RSSFeed, int, error) {
+	if _, err := os.Stat(filepath.Join(dir, "cur")); err != nil {
+		return nil, 0, fmt.Errorf("%s is not a Maildir", dir)
+	}
+
+	feed := newFeed(dir)
+	size := 0
+	for _, sub := range []string{"new", "cur"} {
+		entries, err := os.ReadDir(filepath.Join(dir, sub))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			raw, err := os.ReadFile(filepath.Join(dir, sub, entry.Name()))
+			if err != nil {
+				continue
+			}
+			size += len(raw)
+			item, err := parseMessage(raw)
+			if err != nil {
+				continue
+			}
+			feed.Channel.Item = append(feed.Channel.Item, item)
+		}
+	}
+	return feed, size, nil
+}
+
+func newFeed(path string) *rss.RSSFeed {
+	feed := &rss.RSSFeed{}
+	feed.Channel.Title = filepath.Base(path)
+	return feed
+}
+
+// splitMbox cuts an mbox file into messages on its "From " separator lines
+func splitMbox(data []byte) [][]byte {
+	var messages [][]byte
+	var current bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	started := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "From ") {
+			if started && current.Len() > 0 {
+				messages = append(messages, bytes.Clone(current.Bytes()))
+			}
+			current.Reset()
+			started = true
+			continue
+		}
+		if !started {
+			continue
+		}
+		if quotedFrom.MatchString(line) {
+			line = line[1:]
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+	}
+	if started && current.Len() > 0 {
+		messages = append(messages, current.Bytes())
+	}
+	return messages
+}
+
+func parseMessage(raw []byte) (rss.RSSItem, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return rss.RSSItem{}, err
+	}
+
+	subject, err := headerDecoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+	item := rss.RSSItem{
+		Title:   strings.TrimSpace(subject),
+		PubDate: msg.Header.Get("Date"),
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		item.PubDate = date.UTC().Format(time.RFC1123Z)
+	}
+	if from, err := mail.ParseAddress(msg.Header.Get("From")); err == nil {
+		item.Author = from.Name
+		if item.Author == "" {
+			item.Author = from.Address
+		}
+	}
+
+	id := strings.Trim(strings.TrimSpace(msg.Header.Get("Message-Id")), "<>")
+	if id == "" {
+		// messages without an id still need a stable one to be stored only once
+		sum := sha1.Sum([]byte(item.Title + item.PubDate + item.Author))
+		id = hex.EncodeToString(sum[:]) + "@gator"
+	}
+	item.GUID = id
+	item.Link = "mid:" + id
+
+	item.Description, err = body(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return rss.RSSItem{}, err
+	}
+	return item, nil
+}
+
+// body returns the html part of a message if it has one, otherwise the plain text part
+func body(contentType, encoding string, r io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if params["boundary"] == "" {
+			return "", errors.New("Multipart message has no boundary")
+		}
+		var html, text string
+		parts := multipart.NewReader(r, params["boundary"])
+		for {
+			part, err := parts.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			if part.Header.Get("Content-Type") == "" {
+				partType = "text/plain"
+			}
+			if strings.HasPrefix(part.Header.Get("Content-Disposition"), "attachment") {
+				continue
+			}
+			content, err := body(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil || content == "" {
+				continue
+			}
+			switch {
+			case partType == "text/html" && html == "":
+				html = content
+			case strings.HasPrefix(partType, "multipart/") && html == "":
+				// a nested multipart/alternative has already picked its best part
+				html = content
+			case partType == "text/plain" && text == "":
+				text = content
+			}
+		}
+		if html != "" {
+			return html, nil
+		}
+		return text, nil
+	}
+
+	if mediaType != "text/html" && mediaType != "text/plain" {
+		return "", nil
+	}
+	decoded, err := io.ReadAll(decode(encoding, r))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(decoded)), nil
+}
+
+func decode(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	}
+	return r
+}
@@ -0,0 +1,102 @@
+package mailbox
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const mbox = `From jane@example.com Mon Jun  2 10:00:00 2025
+From: Jane Doe <jane@example.com>
+Subject: =?UTF-8?Q?Caf=C3=A9_news?=
+Date: Mon, 02 Jun 2025 12:00:00 +0200
+Message-Id: <1@example.com>
+
+Plain body
+>From the archive
+
+From bob@example.com Tue Jun  3 10:00:00 2025
+From: bob@example.com
+Subject: Both parts
+Date: Tue, 03 Jun 2025 10:00:00 +0000
+Content-Type: multipart/alternative; boundary="b"
+
+--b
+Content-Type: text/plain
+
+plain version
+--b
+Content-Type: text/html
+Content-Transfer-Encoding: quoted-printable
+
+<p>html =3D better</p>
+--b--
+`
+
+func TestReadMbox(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "news.mbox")
+	err := os.WriteFile(path, []byte(mbox), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed, size, err := ReadMbox(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != len(mbox) || feed.Channel.Title != "news.mbox" {
+		t.Errorf("read %d bytes of %s, want %d", size, feed.Channel.Title, len(mbox))
+	}
+	if len(feed.Channel.Item) != 2 {
+		t.Fatalf("got %d items, want 2", len(feed.Channel.Item))
+	}
+
+	first := feed.Channel.Item[0]
+	if first.Title != "Café news" || first.Author != "Jane Doe" {
+		t.Errorf("first message: subject %q, author %q", first.Title, first.Author)
+	}
+	if first.Link != "mid:1@example.com" || first.PubDate != "Mon, 02 Jun 2025 10:00:00 +0000" {
+		t.Errorf("first message: link %q, date %q", first.Link, first.PubDate)
+	}
+	if first.Description != "Plain body\nFrom the archive" {
+		t.Errorf("first message body = %q", first.Description)
+	}
+
+	second := feed.Channel.Item[1]
+	if second.Author != "bob@example.com" || second.Description != "<p>html = better</p>" {
+		t.Errorf("second message: author %q, body %q", second.Author, second.Description)
+	}
+	if !strings.HasSuffix(second.GUID, "@gator") || second.Link != "mid:"+second.GUID {
+		t.Errorf("a message without an id got guid %q, link %q", second.GUID, second.Link)
+	}
+}
+
+func TestReadMaildir(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"cur", "new", "tmp"} {
+		err := os.Mkdir(filepath.Join(dir, sub), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	message := "From: jane@example.com\nSubject: Hello\nMessage-Id: <2@example.com>\n\nHi\n"
+	for _, name := range []string{"new/1", "cur/2:2,S", "cur/.hidden", "tmp/3"} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(message), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	feed, size, err := ReadMaildir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Channel.Item) != 2 || size != 2*len(message) {
+		t.Errorf("read %d messages and %d bytes, want 2 from new and cur", len(feed.Channel.Item), size)
+	}
+
+	_, _, err = ReadMaildir(t.TempDir())
+	if err == nil {
+		t.Error("ReadMaildir read a folder without cur")
+	}
+}
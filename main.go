@@ -1,20 +1,41 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	_ "github.com/lib/pq" // required for sql interaction
 	"github.com/luckyhut/gator/command"
 	"github.com/luckyhut/gator/config"
 	"github.com/luckyhut/gator/database"
-	"log"
+	"github.com/luckyhut/gator/feedurl"
+	"github.com/luckyhut/gator/logging"
+	"github.com/luckyhut/gator/metrics"
+	"github.com/luckyhut/gator/migrate"
+	"log/slog"
 	"os"
 )
 
+// commands that run without a database at the current schema version
+var skipSchemaCheck = map[string]bool{
+	"migrate": true,
+	"ctl":     true,
+}
+
 func main() {
 	// read config file
 	conf := config.Read()
 
+	// diagnostics go to stderr or the configured log file, command output stays on stdout
+	err := logging.Setup(conf.LogLevel, conf.LogFormat, conf.LogFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	feedurl.MastodonHosts = append(feedurl.MastodonHosts, conf.MastodonHosts...)
+
 	// initialize state and commands
 	state := &command.State{
 		Config: &conf,
@@ -22,29 +43,49 @@ func main() {
 	commands := &command.Commands{
 		Commands_list: make(map[string]func(*command.State, command.Command) error),
 	}
+	commands.Register("migrate", command.HandlerMigrate)
 	commands.Register("login", command.HandlerLogin)
 	commands.Register("register", command.HandlerRegister)
 	commands.Register("reset", command.HandlerReset)
 	commands.Register("users", command.HandlerUsers)
 	commands.Register("agg", command.HandlerAgg)
+	commands.Register("refresh", command.HandlerRefresh)
+	commands.Register("ctl", command.HandlerCtl)
+	commands.Register("prune", command.HandlerPrune)
 	commands.Register("feeds", command.HandlerFeeds)
+	commands.Register("feed", command.HandlerFeed)
 	commands.Register("browse", command.HandlerBrowse)
 	commands.Register("addfeed", command.MiddlewareLoggedIn(command.HandlerAddFeed))
 	commands.Register("follow", command.MiddlewareLoggedIn(command.HandlerFollow))
 	commands.Register("unfollow", command.MiddlewareLoggedIn(command.HandlerUnfollow))
 	commands.Register("following", command.MiddlewareLoggedIn(command.HandlerFollowing))
+	commands.Register("tag", command.MiddlewareLoggedIn(command.HandlerTag))
+	commands.Register("untag", command.MiddlewareLoggedIn(command.HandlerUntag))
+	commands.Register("rename", command.MiddlewareLoggedIn(command.HandlerRename))
+	commands.Register("categories", command.MiddlewareLoggedIn(command.HandlerCategories))
+	commands.Register("search", command.MiddlewareLoggedIn(command.HandlerSearch))
+	commands.Register("read", command.MiddlewareLoggedIn(command.HandlerRead))
+	commands.Register("unread", command.MiddlewareLoggedIn(command.HandlerUnread))
+	commands.Register("mark-all-read", command.MiddlewareLoggedIn(command.HandlerMarkAllRead))
+	commands.Register("star", command.MiddlewareLoggedIn(command.HandlerStar))
+	commands.Register("unstar", command.MiddlewareLoggedIn(command.HandlerUnstar))
+	commands.Register("starred", command.MiddlewareLoggedIn(command.HandlerStarred))
+	commands.Register("rule", command.MiddlewareLoggedIn(command.HandlerRule))
+	commands.Register("scrape", command.MiddlewareLoggedIn(command.HandlerScrape))
 
 	// open connection to database
 	db, err := sql.Open("postgres", state.Config.DbUrl)
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("Error opening database", "err", err)
+		os.Exit(1)
 	}
-	dbQueries := database.New(db)
+	dbQueries := database.New(metrics.DB(db))
 	state.Db = dbQueries
+	state.Conn = db
 
 	// get command
 	if len(os.Args) < 2 {
-		fmt.Println("Error, not enough arguments")
+		fmt.Fprintln(os.Stderr, "Error, not enough arguments")
 		os.Exit(1)
 	}
 
@@ -52,10 +93,30 @@ func main() {
 		Name: os.Args[1],
 		Args: os.Args[2:],
 	}
+	if _, exists := commands.Commands_list[current_command.Name]; !exists {
+		fmt.Fprintf(os.Stderr, "Unknown command %s\n", current_command.Name)
+		os.Exit(1)
+	}
+
+	// every other command needs the schema these queries were written against
+	if !skipSchemaCheck[current_command.Name] {
+		migrations, err := command.Migrations()
+		if err == nil {
+			err = migrate.Check(context.Background(), db, migrations)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
 
 	err = commands.Run(state, *current_command)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
+		var exitErr *command.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,63 @@
+package memstore
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/database"
+	"slices"
+)
+
+func (s *Store) CreateFeedFetch(ctx context.Context, arg database.CreateFeedFetchParams) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	for _, fetch := range s.feedFetches {
+		if fetch.ID == arg.ID {
+			return conflict("feed_fetches_pkey")
+		}
+	}
+	if s.feed(arg.FeedID) < 0 {
+		return missing("feed_fetches_feed_id_fkey")
+	}
+	s.feedFetches = append(s.feedFetches, database.FeedFetch(arg))
+	return nil
+}
+
+func (s *Store) GetFeedFetches(ctx context.Context, arg database.GetFeedFetchesParams) ([]database.FeedFetch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.newestFetches(arg.FeedID, arg.Limit)
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return items, nil
+}
+
+func (s *Store) PruneFeedFetches(ctx context.Context, arg database.PruneFeedFetchesParams) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	keep := make(map[uuid.UUID]bool)
+	for _, fetch := range s.newestFetches(arg.FeedID, arg.Limit) {
+		keep[fetch.ID] = true
+	}
+	s.feedFetches = filter(s.feedFetches, func(f *database.FeedFetch) bool {
+		return f.FeedID != arg.FeedID || keep[f.ID]
+	})
+	return nil
+}
+
+// newestFetches returns up to limit of a feed's fetches, newest first
+func (s *Store) newestFetches(feedID uuid.UUID, limit int32) []database.FeedFetch {
+	var items []database.FeedFetch
+	for _, fetch := range s.feedFetches {
+		if fetch.FeedID == feedID {
+			items = append(items, fetch)
+		}
+	}
+	slices.SortStableFunc(items, func(a, b database.FeedFetch) int {
+		return b.StartedAt.Compare(a.StartedAt)
+	})
+	if int(limit) < len(items) {
+		items = items[:max(limit, 0)]
+	}
+	return items
+}
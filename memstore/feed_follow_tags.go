@@ -0,0 +1,30 @@
+package memstore
+
+import (
+	"context"
+	"github.com/luckyhut/gator/database"
+	"slices"
+)
+
+func (s *Store) TagFeedFollow(ctx context.Context, arg database.TagFeedFollowParams) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	if s.tagged(arg.FeedFollowID, arg.Tag) {
+		return nil
+	}
+	if !slices.ContainsFunc(s.feedFollows, func(f database.FeedFollow) bool { return f.ID == arg.FeedFollowID }) {
+		return missing("feed_follow_tags_feed_follow_id_fkey")
+	}
+	s.feedFollowTags = append(s.feedFollowTags, database.FeedFollowTag(arg))
+	return nil
+}
+
+func (s *Store) UntagFeedFollow(ctx context.Context, arg database.UntagFeedFollowParams) (int64, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	before := len(s.feedFollowTags)
+	s.feedFollowTags = filter(s.feedFollowTags, func(t *database.FeedFollowTag) bool {
+		return t.FeedFollowID != arg.FeedFollowID || t.Tag != arg.Tag
+	})
+	return int64(before - len(s.feedFollowTags)), nil
+}
@@ -0,0 +1,148 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/database"
+	"slices"
+	"strings"
+)
+
+func (s *Store) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	for _, follow := range s.feedFollows {
+		if follow.ID == arg.ID {
+			return conflict("feed_follows_pkey")
+		}
+	}
+	if s.follows(arg.UserID, arg.FeedID) {
+		return conflict("feed_follows_user_id_feed_id_key")
+	}
+	if s.user(arg.UserID) < 0 {
+		return missing("feed_follows_user_id_fkey")
+	}
+	if s.feed(arg.FeedID) < 0 {
+		return missing("feed_follows_feed_id_fkey")
+	}
+	s.feedFollows = append(s.feedFollows, database.FeedFollow{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		FeedID:    arg.FeedID,
+	})
+	return nil
+}
+
+func (s *Store) DeleteFeedFollows(ctx context.Context, feedID uuid.UUID) (int64, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	before := len(s.feedFollows)
+	s.feedFollows = filter(s.feedFollows, func(f *database.FeedFollow) bool { return f.FeedID != feedID })
+	s.dropFollowTags()
+	return int64(before - len(s.feedFollows)), nil
+}
+
+func (s *Store) GetFeedFollow(ctx context.Context, arg database.GetFeedFollowParams) (database.FeedFollow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.feedFollow(arg.UserID, arg.FeedID)
+	if i < 0 {
+		return database.FeedFollow{}, sql.ErrNoRows
+	}
+	return s.feedFollows[i], nil
+}
+
+func (s *Store) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.GetFeedFollowsForUserRow
+	for _, follow := range s.feedFollows {
+		if follow.UserID != userID {
+			continue
+		}
+		var unread int64
+		for _, post := range s.posts {
+			if post.FeedID == follow.FeedID && !s.read(userID, post.ID) {
+				unread++
+			}
+		}
+		row := database.GetFeedFollowsForUserRow{
+			FeedName:    s.feedTitle(userID, follow.FeedID),
+			UserName:    s.users[s.user(follow.UserID)].Name,
+			UnreadCount: unread,
+		}
+		// the left join gives one row per tag, or one without a tag
+		var tags []string
+		for _, t := range s.feedFollowTags {
+			if t.FeedFollowID == follow.ID {
+				tags = append(tags, t.Tag)
+			}
+		}
+		if len(tags) == 0 {
+			items = append(items, row)
+		}
+		for _, tag := range tags {
+			row.Tag = sql.NullString{String: tag, Valid: true}
+			items = append(items, row)
+		}
+	}
+	// ORDER BY t.tag NULLS LAST, feed_name, where a null name also sorts last
+	slices.SortStableFunc(items, func(a, b database.GetFeedFollowsForUserRow) int {
+		if c := nullsLast(a.Tag, b.Tag); c != 0 {
+			return c
+		}
+		return nullsLast(a.FeedName, b.FeedName)
+	})
+	return items, nil
+}
+
+func (s *Store) RenameFeedFollow(ctx context.Context, arg database.RenameFeedFollowParams) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	for i := range s.feedFollows {
+		if s.feedFollows[i].ID == arg.ID {
+			s.feedFollows[i].Title = arg.Title
+			s.feedFollows[i].UpdatedAt = arg.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (s *Store) ResetFeedFollow(ctx context.Context) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	s.feedFollows = nil
+	s.feedFollowTags = nil
+	return nil
+}
+
+func (s *Store) Unfollow(ctx context.Context, arg database.UnfollowParams) ([]uuid.UUID, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	var items []uuid.UUID
+	s.feedFollows = filter(s.feedFollows, func(f *database.FeedFollow) bool {
+		if f.UserID == arg.UserID && f.FeedID == arg.FeedID {
+			items = append(items, f.UserID)
+			return false
+		}
+		return true
+	})
+	s.dropFollowTags()
+	return items, nil
+}
+
+// nullsLast orders a before b the way ORDER BY x ASC does for text, with nulls last
+func nullsLast(a, b sql.NullString) int {
+	switch {
+	case !a.Valid && !b.Valid:
+		return 0
+	case !a.Valid:
+		return 1
+	case !b.Valid:
+		return -1
+	}
+	return strings.Compare(a.String, b.String)
+}
@@ -0,0 +1,66 @@
+package memstore
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/database"
+	"slices"
+)
+
+func (s *Store) CreateFeedRule(ctx context.Context, arg database.CreateFeedRuleParams) (database.FeedRule, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	var position int32
+	for _, rule := range s.feedRules {
+		if rule.ID == arg.ID {
+			return database.FeedRule{}, conflict("feed_rules_pkey")
+		}
+		if rule.FeedID == arg.FeedID {
+			position = max(position, rule.Position)
+		}
+	}
+	if s.feed(arg.FeedID) < 0 {
+		return database.FeedRule{}, missing("feed_rules_feed_id_fkey")
+	}
+	if s.user(arg.UserID) < 0 {
+		return database.FeedRule{}, missing("feed_rules_user_id_fkey")
+	}
+	rule := database.FeedRule{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		FeedID:      arg.FeedID,
+		UserID:      arg.UserID,
+		Position:    position + 1,
+		Action:      arg.Action,
+		Field:       arg.Field,
+		Pattern:     arg.Pattern,
+		Replacement: arg.Replacement,
+	}
+	s.feedRules = append(s.feedRules, rule)
+	return rule, nil
+}
+
+func (s *Store) DeleteFeedRule(ctx context.Context, arg database.DeleteFeedRuleParams) (int64, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	before := len(s.feedRules)
+	s.feedRules = filter(s.feedRules, func(r *database.FeedRule) bool {
+		return r.FeedID != arg.FeedID || r.Position != arg.Position
+	})
+	return int64(before - len(s.feedRules)), nil
+}
+
+func (s *Store) GetFeedRules(ctx context.Context, feedID uuid.UUID) ([]database.FeedRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.FeedRule
+	for _, rule := range s.feedRules {
+		if rule.FeedID == feedID {
+			items = append(items, rule)
+		}
+	}
+	slices.SortFunc(items, func(a, b database.FeedRule) int {
+		return int(a.Position - b.Position)
+	})
+	return items, nil
+}
@@ -0,0 +1,35 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/database"
+)
+
+func (s *Store) GetFeedScraper(ctx context.Context, feedID uuid.UUID) (database.FeedScraper, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, scraper := range s.feedScrapers {
+		if scraper.FeedID == feedID {
+			return scraper, nil
+		}
+	}
+	return database.FeedScraper{}, sql.ErrNoRows
+}
+
+func (s *Store) UpsertFeedScraper(ctx context.Context, arg database.UpsertFeedScraperParams) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	for i := range s.feedScrapers {
+		if s.feedScrapers[i].FeedID == arg.FeedID {
+			s.feedScrapers[i] = database.FeedScraper(arg)
+			return nil
+		}
+	}
+	if s.feed(arg.FeedID) < 0 {
+		return missing("feed_scrapers_feed_id_fkey")
+	}
+	s.feedScrapers = append(s.feedScrapers, database.FeedScraper(arg))
+	return nil
+}
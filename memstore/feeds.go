@@ -0,0 +1,284 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/database"
+	"slices"
+	"time"
+)
+
+func (s *Store) ClaimFeed(ctx context.Context, arg database.ClaimFeedParams) (database.Feed, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	i := s.feed(arg.ID)
+	if i < 0 || !s.leaseFree(&s.feeds[i]) {
+		return database.Feed{}, sql.ErrNoRows
+	}
+	return s.lease(i, arg.LeaseSeconds), nil
+}
+
+func (s *Store) ClaimNextFeed(ctx context.Context, leaseSeconds int32) (database.Feed, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	next := -1
+	for i := range s.feeds {
+		if !s.leaseFree(&s.feeds[i]) || !s.followed(s.feeds[i].ID) {
+			continue
+		}
+		if next < 0 || nullsFirst(s.feeds[i].LastFetchedAt, s.feeds[next].LastFetchedAt) < 0 {
+			next = i
+		}
+	}
+	if next < 0 {
+		return database.Feed{}, sql.ErrNoRows
+	}
+	return s.lease(next, leaseSeconds), nil
+}
+
+func (s *Store) leaseFree(feed *database.Feed) bool {
+	return !feed.LeaseUntil.Valid || feed.LeaseUntil.Time.Before(s.now())
+}
+
+func (s *Store) lease(i int, seconds int32) database.Feed {
+	until := s.now().Add(time.Duration(seconds) * time.Second)
+	s.feeds[i].LeaseUntil = sql.NullTime{Time: until, Valid: true}
+	return s.feeds[i]
+}
+
+func (s *Store) ClearOrphanedFeeds(ctx context.Context) (int64, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	var n int64
+	for i := range s.feeds {
+		if s.feeds[i].OrphanedAt.Valid && s.followed(s.feeds[i].ID) {
+			s.feeds[i].OrphanedAt = sql.NullTime{}
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) CountFeeds(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, feed := range s.feeds {
+		if s.followed(feed.ID) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	if s.feed(arg.ID) >= 0 {
+		return database.Feed{}, conflict("feeds_pkey")
+	}
+	if s.feedByUrl(arg.Url) >= 0 {
+		return database.Feed{}, conflict("feeds_url_key")
+	}
+	if arg.UserID.Valid && s.user(arg.UserID.UUID) < 0 {
+		return database.Feed{}, missing("feeds_user_id_fkey")
+	}
+	feed := database.Feed{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		Name:      arg.Name,
+		Url:       arg.Url,
+		UserID:    arg.UserID,
+		Kind:      arg.Kind,
+	}
+	s.feeds = append(s.feeds, feed)
+	return feed, nil
+}
+
+func (s *Store) DeleteFeed(ctx context.Context, id uuid.UUID) (int64, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	if s.feed(id) < 0 || s.followed(id) || slices.ContainsFunc(s.posts, func(p database.Post) bool { return p.FeedID == id }) {
+		return 0, nil
+	}
+	return 1, s.deleteFeeds(func(f *database.Feed) bool { return f.ID != id })
+}
+
+func (s *Store) GetAllFeeds(ctx context.Context) ([]database.GetAllFeedsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.GetAllFeedsRow
+	for _, feed := range s.feeds {
+		if !feed.UserID.Valid {
+			continue
+		}
+		u := s.user(feed.UserID.UUID)
+		items = append(items, database.GetAllFeedsRow{
+			Name:        feed.Name,
+			Url:         feed.Url,
+			Name_2:      s.users[u].Name,
+			Link:        feed.Link,
+			Description: feed.Description,
+		})
+	}
+	return items, nil
+}
+
+func (s *Store) GetFeed(ctx context.Context, url sql.NullString) (uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.feedByUrl(url)
+	if i < 0 {
+		return uuid.UUID{}, sql.ErrNoRows
+	}
+	return s.feeds[i].ID, nil
+}
+
+func (s *Store) GetFeedByUrl(ctx context.Context, url sql.NullString) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.feedByUrl(url)
+	if i < 0 {
+		return database.Feed{}, sql.ErrNoRows
+	}
+	return s.feeds[i], nil
+}
+
+func (s *Store) GetFeedQueueLag(ctx context.Context, now time.Time) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	oldest := now
+	for _, feed := range s.feeds {
+		if !s.followed(feed.ID) {
+			continue
+		}
+		waiting := feed.CreatedAt
+		if feed.LastFetchedAt.Valid {
+			waiting = feed.LastFetchedAt.Time
+		}
+		if waiting.Before(oldest) {
+			oldest = waiting
+		}
+	}
+	return now.Sub(oldest).Seconds(), nil
+}
+
+func (s *Store) GetFeedsToFetch(ctx context.Context, dueBefore time.Time) ([]database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.Feed
+	for _, feed := range s.feeds {
+		if !s.followed(feed.ID) {
+			continue
+		}
+		if !feed.LastFetchedAt.Valid || feed.LastFetchedAt.Time.Before(dueBefore) {
+			items = append(items, feed)
+		}
+	}
+	slices.SortStableFunc(items, func(a, b database.Feed) int {
+		return nullsFirst(a.LastFetchedAt, b.LastFetchedAt)
+	})
+	return items, nil
+}
+
+func (s *Store) GetOrphanedFeeds(ctx context.Context, orphanedBefore time.Time) ([]database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.Feed
+	for _, feed := range s.feeds {
+		if feed.OrphanedAt.Valid && feed.OrphanedAt.Time.Before(orphanedBefore) && !s.followed(feed.ID) {
+			items = append(items, feed)
+		}
+	}
+	slices.SortStableFunc(items, func(a, b database.Feed) int {
+		return a.OrphanedAt.Time.Compare(b.OrphanedAt.Time)
+	})
+	return items, nil
+}
+
+func (s *Store) MarkFeedFetched(ctx context.Context, arg database.MarkFeedFetchedParams) (database.Feed, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	i := s.feed(arg.ID)
+	if i < 0 {
+		return database.Feed{}, sql.ErrNoRows
+	}
+	s.feeds[i].LastFetchedAt = sql.NullTime{Time: arg.FetchedAt, Valid: true}
+	s.feeds[i].LeaseUntil = sql.NullTime{}
+	s.feeds[i].UpdatedAt = arg.FetchedAt
+	return s.feeds[i], nil
+}
+
+func (s *Store) MarkOrphanedFeeds(ctx context.Context, orphanedAt sql.NullTime) (int64, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	var n int64
+	for i := range s.feeds {
+		if !s.feeds[i].OrphanedAt.Valid && !s.followed(s.feeds[i].ID) {
+			s.feeds[i].OrphanedAt = orphanedAt
+			n++
+		}
+	}
+	return n, nil
+}
+
+// LockFeed does nothing, transactions on the store already run one at a time
+func (s *Store) LockFeed(ctx context.Context, id uuid.UUID) error {
+	return nil
+}
+
+// NotifyFeedAdded does nothing, no agg can listen to an in-memory store
+func (s *Store) NotifyFeedAdded(ctx context.Context, url string) error {
+	return nil
+}
+
+func (s *Store) ReleaseFeed(ctx context.Context, id uuid.UUID) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	i := s.feed(id)
+	if i >= 0 {
+		s.feeds[i].LeaseUntil = sql.NullTime{}
+	}
+	return nil
+}
+
+func (s *Store) ResetFeeds(ctx context.Context) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	return s.deleteFeeds(func(*database.Feed) bool { return false })
+}
+
+func (s *Store) SetFeedRetention(ctx context.Context, arg database.SetFeedRetentionParams) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	i := s.feed(arg.ID)
+	if i >= 0 {
+		s.feeds[i].RetentionMaxAgeSeconds = arg.RetentionMaxAgeSeconds
+		s.feeds[i].RetentionMaxPosts = arg.RetentionMaxPosts
+		s.feeds[i].UpdatedAt = s.now()
+	}
+	return nil
+}
+
+func (s *Store) UpdateFeedMetadata(ctx context.Context, arg database.UpdateFeedMetadataParams) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	i := s.feed(arg.ID)
+	if i < 0 {
+		return nil
+	}
+	feed := &s.feeds[i]
+	feed.Title = arg.Title
+	feed.Description = arg.Description
+	feed.Link = arg.Link
+	feed.ImageUrl = arg.ImageUrl
+	feed.Language = arg.Language
+	feed.Generator = arg.Generator
+	if !feed.Name.Valid || feed.Name.String == "" {
+		feed.Name = arg.Title
+	}
+	feed.UpdatedAt = s.now()
+	return nil
+}
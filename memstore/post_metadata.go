@@ -0,0 +1,127 @@
+package memstore
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/database"
+	"slices"
+	"strings"
+)
+
+func (s *Store) CreatePostAuthor(ctx context.Context, arg database.CreatePostAuthorParams) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	for _, pa := range s.postAuthors {
+		if pa.PostID == arg.PostID && pa.AuthorID == arg.AuthorID {
+			return nil
+		}
+	}
+	if s.post(arg.PostID) < 0 {
+		return missing("post_authors_post_id_fkey")
+	}
+	if !slices.ContainsFunc(s.authors, func(a database.Author) bool { return a.ID == arg.AuthorID }) {
+		return missing("post_authors_author_id_fkey")
+	}
+	s.postAuthors = append(s.postAuthors, database.PostAuthor(arg))
+	return nil
+}
+
+func (s *Store) CreatePostCategory(ctx context.Context, arg database.CreatePostCategoryParams) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	if s.hasCategory(arg.PostID, arg.Category) {
+		return nil
+	}
+	if s.post(arg.PostID) < 0 {
+		return missing("post_categories_post_id_fkey")
+	}
+	s.postCategories = append(s.postCategories, database.PostCategory(arg))
+	return nil
+}
+
+func (s *Store) DeletePostAuthors(ctx context.Context, postID uuid.UUID) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	s.postAuthors = filter(s.postAuthors, func(pa *database.PostAuthor) bool { return pa.PostID != postID })
+	return nil
+}
+
+func (s *Store) DeletePostCategories(ctx context.Context, postID uuid.UUID) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	s.postCategories = filter(s.postCategories, func(pc *database.PostCategory) bool { return pc.PostID != postID })
+	return nil
+}
+
+func (s *Store) GetTopCategoriesForUser(ctx context.Context, arg database.GetTopCategoriesForUserParams) ([]database.GetTopCategoriesForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int64)
+	for _, pc := range s.postCategories {
+		if s.follows(arg.UserID, s.posts[s.post(pc.PostID)].FeedID) {
+			counts[pc.Category]++
+		}
+	}
+	var items []database.GetTopCategoriesForUserRow
+	for category, count := range counts {
+		items = append(items, database.GetTopCategoriesForUserRow{Category: category, PostCount: count})
+	}
+	slices.SortFunc(items, func(a, b database.GetTopCategoriesForUserRow) int {
+		if a.PostCount != b.PostCount {
+			return int(b.PostCount - a.PostCount)
+		}
+		return strings.Compare(a.Category, b.Category)
+	})
+	if int(arg.Limit) < len(items) {
+		items = items[:max(arg.Limit, 0)]
+	}
+	return items, nil
+}
+
+func (s *Store) UpsertAuthor(ctx context.Context, arg database.UpsertAuthorParams) (uuid.UUID, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	for i := range s.authors {
+		if s.authors[i].Normalized == arg.Normalized {
+			s.authors[i].Name = arg.Name
+			return s.authors[i].ID, nil
+		}
+		if s.authors[i].ID == arg.ID {
+			return uuid.UUID{}, conflict("authors_pkey")
+		}
+	}
+	s.authors = append(s.authors, database.Author(arg))
+	return arg.ID, nil
+}
+
+func (s *Store) hasCategory(postID uuid.UUID, category string) bool {
+	for _, pc := range s.postCategories {
+		if pc.PostID == postID && pc.Category == category {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAuthor reports whether a post is by the author with this normalized name
+func (s *Store) hasAuthor(postID uuid.UUID, normalized string) bool {
+	for _, pa := range s.postAuthors {
+		if pa.PostID != postID {
+			continue
+		}
+		for _, author := range s.authors {
+			if author.ID == pa.AuthorID && author.Normalized == normalized {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Store) ResetAuthors(ctx context.Context) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	s.authors = nil
+	s.postAuthors = nil
+	return nil
+}
@@ -0,0 +1,71 @@
+package memstore
+
+import (
+	"context"
+	"github.com/luckyhut/gator/database"
+)
+
+func (s *Store) GetUnreadPosts(ctx context.Context, arg database.GetUnreadPostsParams) ([]database.GetUnreadPostsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []database.GetUnreadPostsRow
+	for _, post := range s.posts {
+		if !s.follows(arg.UserID, post.FeedID) || s.read(arg.UserID, post.ID) {
+			continue
+		}
+		feed := s.feeds[s.feed(post.FeedID)]
+		if arg.FeedUrl.Valid && (!feed.Url.Valid || feed.Url.String != arg.FeedUrl.String) {
+			continue
+		}
+		rows = append(rows, database.GetUnreadPostsRow{
+			ID:          post.ID,
+			PublishedAt: post.PublishedAt,
+			CreatedAt:   post.CreatedAt,
+		})
+	}
+	return rows, nil
+}
+
+func (s *Store) MarkAllRead(ctx context.Context, arg database.MarkAllReadParams) (int64, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	var n int64
+	for _, post := range s.posts {
+		if !s.follows(arg.UserID, post.FeedID) || s.read(arg.UserID, post.ID) {
+			continue
+		}
+		feed := s.feeds[s.feed(post.FeedID)]
+		if arg.FeedUrl.Valid && (!feed.Url.Valid || feed.Url.String != arg.FeedUrl.String) {
+			continue
+		}
+		s.postReads = append(s.postReads, database.PostRead{UserID: arg.UserID, PostID: post.ID, ReadAt: arg.ReadAt})
+		n++
+	}
+	return n, nil
+}
+
+func (s *Store) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	if s.read(arg.UserID, arg.PostID) {
+		return nil
+	}
+	if s.user(arg.UserID) < 0 {
+		return missing("post_reads_user_id_fkey")
+	}
+	if s.post(arg.PostID) < 0 {
+		return missing("post_reads_post_id_fkey")
+	}
+	s.postReads = append(s.postReads, database.PostRead(arg))
+	return nil
+}
+
+func (s *Store) MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) (int64, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	before := len(s.postReads)
+	s.postReads = filter(s.postReads, func(r *database.PostRead) bool {
+		return r.UserID != arg.UserID || r.PostID != arg.PostID
+	})
+	return int64(before - len(s.postReads)), nil
+}
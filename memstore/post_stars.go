@@ -0,0 +1,59 @@
+package memstore
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/database"
+	"slices"
+)
+
+func (s *Store) GetStarredPosts(ctx context.Context, userID uuid.UUID) ([]database.GetStarredPostsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stars := filter(slices.Clone(s.postStars), func(star *database.PostStar) bool { return star.UserID == userID })
+	slices.SortStableFunc(stars, func(a, b database.PostStar) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	var rows []database.GetStarredPostsRow
+	for _, star := range stars {
+		post := s.posts[s.post(star.PostID)]
+		feed := s.feeds[s.feed(post.FeedID)]
+		rows = append(rows, database.GetStarredPostsRow{
+			PostID:      post.ID,
+			StarredAt:   star.CreatedAt,
+			Title:       post.Title,
+			Url:         post.Url,
+			Description: post.Description,
+			PublishedAt: post.PublishedAt,
+			FeedName:    s.feedTitle(userID, feed.ID),
+			FeedUrl:     feed.Url,
+		})
+	}
+	return rows, nil
+}
+
+func (s *Store) StarPost(ctx context.Context, arg database.StarPostParams) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	for _, star := range s.postStars {
+		if star.UserID == arg.UserID && star.PostID == arg.PostID {
+			return nil
+		}
+	}
+	if s.user(arg.UserID) < 0 {
+		return missing("post_stars_user_id_fkey")
+	}
+	if s.post(arg.PostID) < 0 {
+		return missing("post_stars_post_id_fkey")
+	}
+	s.postStars = append(s.postStars, database.PostStar(arg))
+	return nil
+}
+
+func (s *Store) UnstarPost(ctx context.Context, arg database.UnstarPostParams) (int64, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	before := len(s.postStars)
+	s.postStars = filter(s.postStars, func(star *database.PostStar) bool {
+		return star.UserID != arg.UserID || star.PostID != arg.PostID
+	})
+	return int64(before - len(s.postStars)), nil
+}
@@ -0,0 +1,300 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/database"
+	"github.com/luckyhut/gator/search"
+	"slices"
+	"strings"
+	"time"
+)
+
+func (s *Store) CreatePost(ctx context.Context, arg database.CreatePostParams) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	for _, post := range s.posts {
+		if post.ID == arg.ID {
+			return conflict("posts_pkey")
+		}
+		if post.Url == arg.Url {
+			return conflict("posts_url_key")
+		}
+	}
+	if s.feed(arg.FeedID) < 0 {
+		return missing("posts_feed_id_fkey")
+	}
+	s.posts = append(s.posts, database.Post{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		Title:       arg.Title,
+		Url:         arg.Url,
+		Description: arg.Description,
+		PublishedAt: arg.PublishedAt,
+		FeedID:      arg.FeedID,
+	})
+	return nil
+}
+
+func (s *Store) DeletePosts(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	gone := make(map[uuid.UUID]bool)
+	for _, id := range ids {
+		if !s.starred(id) {
+			gone[id] = true
+		}
+	}
+	return s.deletePosts(gone), nil
+}
+
+func (s *Store) DeleteFeedPosts(ctx context.Context, feedID uuid.UUID) (int64, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	gone := make(map[uuid.UUID]bool)
+	if s.followed(feedID) {
+		return 0, nil
+	}
+	for _, post := range s.posts {
+		if post.FeedID == feedID && !s.starred(post.ID) {
+			gone[post.ID] = true
+		}
+	}
+	return s.deletePosts(gone), nil
+}
+
+// deletePosts removes the posts in gone along with the rows that cascade from them
+func (s *Store) deletePosts(gone map[uuid.UUID]bool) int64 {
+	before := len(s.posts)
+	s.posts = filter(s.posts, func(p *database.Post) bool { return !gone[p.ID] })
+	s.postCategories = filter(s.postCategories, func(pc *database.PostCategory) bool { return !gone[pc.PostID] })
+	s.postAuthors = filter(s.postAuthors, func(pa *database.PostAuthor) bool { return !gone[pa.PostID] })
+	s.postStars = filter(s.postStars, func(ps *database.PostStar) bool { return !gone[ps.PostID] })
+	s.postReads = filter(s.postReads, func(r *database.PostRead) bool { return !gone[r.PostID] })
+	return int64(before - len(s.posts))
+}
+
+func (s *Store) GetPostByUrl(ctx context.Context, url string) (uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, post := range s.posts {
+		if post.Url == url {
+			return post.ID, nil
+		}
+	}
+	return uuid.Nil, sql.ErrNoRows
+}
+
+func (s *Store) GetPostsByIdPrefix(ctx context.Context, prefix string) ([]uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []uuid.UUID
+	for _, post := range s.posts {
+		if strings.HasPrefix(post.ID.String(), prefix) {
+			items = append(items, post.ID)
+			if len(items) == 2 {
+				break
+			}
+		}
+	}
+	return items, nil
+}
+
+func (s *Store) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.GetPostsForUserRow
+	for _, post := range s.posts {
+		if !s.follows(arg.UserID, post.FeedID) {
+			continue
+		}
+		if arg.Category.Valid && !s.hasCategory(post.ID, arg.Category.String) {
+			continue
+		}
+		if arg.Author.Valid && !s.hasAuthor(post.ID, arg.Author.String) {
+			continue
+		}
+		if arg.UnreadOnly && s.read(arg.UserID, post.ID) {
+			continue
+		}
+		if arg.Tag.Valid && !s.tagged(s.feedFollows[s.feedFollow(arg.UserID, post.FeedID)].ID, arg.Tag.String) {
+			continue
+		}
+		items = append(items, database.GetPostsForUserRow{
+			ID:          post.ID,
+			CreatedAt:   post.CreatedAt,
+			UpdatedAt:   post.UpdatedAt,
+			Title:       post.Title,
+			Url:         post.Url,
+			Description: post.Description,
+			PublishedAt: post.PublishedAt,
+			FeedID:      post.FeedID,
+			Guid:        post.Guid,
+			FeedName:    s.feedTitle(arg.UserID, post.FeedID),
+		})
+	}
+	// ORDER BY published_at DESC puts posts without a date first
+	slices.SortStableFunc(items, func(a, b database.GetPostsForUserRow) int {
+		switch {
+		case !a.PublishedAt.Valid && !b.PublishedAt.Valid:
+			return 0
+		case !a.PublishedAt.Valid:
+			return -1
+		case !b.PublishedAt.Valid:
+			return 1
+		}
+		return strings.Compare(b.PublishedAt.String, a.PublishedAt.String)
+	})
+	return items, nil
+}
+
+func (s *Store) GetPostsToPrune(ctx context.Context, arg database.GetPostsToPruneParams) ([]database.GetPostsToPruneRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := arg.Now
+	var items []database.GetPostsToPruneRow
+	for _, feed := range s.feeds {
+		maxAge := feed.RetentionMaxAgeSeconds
+		if !maxAge.Valid {
+			maxAge = arg.DefaultMaxAgeSeconds
+		}
+		maxPosts := feed.RetentionMaxPosts
+		if !maxPosts.Valid {
+			maxPosts = arg.DefaultMaxPosts
+		}
+
+		var posts []database.Post
+		for _, post := range s.posts {
+			if post.FeedID == feed.ID && !s.starred(post.ID) {
+				posts = append(posts, post)
+			}
+		}
+		// newest first, so a post's index is how many newer posts the feed has
+		slices.SortFunc(posts, func(a, b database.Post) int {
+			if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+				return c
+			}
+			return bytes.Compare(a.ID[:], b.ID[:])
+		})
+		var pruned []database.GetPostsToPruneRow
+		for i, post := range posts {
+			tooOld := maxAge.Valid && post.CreatedAt.Before(now.Add(-time.Duration(maxAge.Int64)*time.Second))
+			tooMany := maxPosts.Valid && int64(i) >= int64(maxPosts.Int32)
+			if tooOld || tooMany {
+				pruned = append(pruned, database.GetPostsToPruneRow{ID: post.ID, FeedID: feed.ID, FeedUrl: feed.Url})
+			}
+		}
+		// oldest first within a feed
+		slices.Reverse(pruned)
+		items = append(items, pruned...)
+	}
+	slices.SortStableFunc(items, func(a, b database.GetPostsToPruneRow) int {
+		return strings.Compare(a.FeedUrl.String, b.FeedUrl.String)
+	})
+	return items, nil
+}
+
+// SearchPosts matches whole words without the stemming Postgres does, and ranks
+// posts by how often the query's words appear in them
+func (s *Store) SearchPosts(ctx context.Context, arg database.SearchPostsParams) ([]database.SearchPostsRow, error) {
+	q, err := search.ParseTSQuery(arg.Query)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.SearchPostsRow
+	for _, post := range s.posts {
+		if !s.follows(arg.UserID, post.FeedID) {
+			continue
+		}
+		hits := q.Match(post.Title.String + "\n" + post.Description.String)
+		if hits == 0 {
+			continue
+		}
+		items = append(items, database.SearchPostsRow{
+			ID:            post.ID,
+			Title:         post.Title,
+			Url:           post.Url,
+			PublishedAt:   post.PublishedAt,
+			FeedName:      s.feedTitle(arg.UserID, post.FeedID),
+			Rank:          float64(hits),
+			TitleHeadline: q.Highlight(post.Title.String, "**", "**"),
+			Headline:      q.Highlight(post.Description.String, "**", "**"),
+		})
+	}
+	slices.SortStableFunc(items, func(a, b database.SearchPostsRow) int {
+		if a.Rank != b.Rank {
+			if a.Rank > b.Rank {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(b.PublishedAt.String, a.PublishedAt.String)
+	})
+	if int(arg.Limit) < len(items) {
+		items = items[:max(arg.Limit, 0)]
+	}
+	return items, nil
+}
+
+func (s *Store) UpsertPost(ctx context.Context, arg database.UpsertPostParams) (database.UpsertPostRow, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	for i := range s.posts {
+		post := &s.posts[i]
+		if post.Url != arg.Url {
+			continue
+		}
+		// the url belongs to another feed, or nothing changed
+		if post.FeedID != arg.FeedID || (same(post.Title, arg.Title) &&
+			same(post.Description, arg.Description) &&
+			same(post.PublishedAt, arg.PublishedAt) &&
+			same(post.Guid, arg.Guid)) {
+			return database.UpsertPostRow{}, sql.ErrNoRows
+		}
+		post.Title = arg.Title
+		post.Description = arg.Description
+		post.PublishedAt = arg.PublishedAt
+		post.Guid = arg.Guid
+		post.UpdatedAt = arg.UpdatedAt
+		return database.UpsertPostRow{ID: post.ID, Inserted: false}, nil
+	}
+	if s.post(arg.ID) >= 0 {
+		return database.UpsertPostRow{}, conflict("posts_pkey")
+	}
+	if s.feed(arg.FeedID) < 0 {
+		return database.UpsertPostRow{}, missing("posts_feed_id_fkey")
+	}
+	s.posts = append(s.posts, database.Post{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		Title:       arg.Title,
+		Url:         arg.Url,
+		Description: arg.Description,
+		PublishedAt: arg.PublishedAt,
+		FeedID:      arg.FeedID,
+		Guid:        arg.Guid,
+	})
+	return database.UpsertPostRow{ID: arg.ID, Inserted: true}, nil
+}
+
+// same is NOT (a IS DISTINCT FROM b)
+func same(a, b sql.NullString) bool {
+	return a.Valid == b.Valid && (!a.Valid || a.String == b.String)
+}
+
+func (s *Store) ResetPosts(ctx context.Context) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	s.posts = nil
+	s.postCategories = nil
+	s.postAuthors = nil
+	s.postStars = nil
+	s.postReads = nil
+	return nil
+}
@@ -0,0 +1,279 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/database"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Store keeps gator's tables in memory. It answers every query the way the
+// Postgres schema would, including unique and foreign key constraints and
+// sql.ErrNoRows, so it can stand in for the database in tests and embedded setups.
+type Store struct {
+	mu sync.Mutex
+	// held for the whole of a transaction, and by writes outside one
+	txMu sync.Mutex
+
+	// tables in insertion order, which is the order Postgres returns them in unless a query sorts
+	users          []database.User
+	feeds          []database.Feed
+	feedFollows    []database.FeedFollow
+	feedFollowTags []database.FeedFollowTag
+	feedFetches    []database.FeedFetch
+	feedRules      []database.FeedRule
+	feedScrapers   []database.FeedScraper
+	posts          []database.Post
+	authors        []database.Author
+	postAuthors    []database.PostAuthor
+	postCategories []database.PostCategory
+	postStars      []database.PostStar
+	postReads      []database.PostRead
+
+	// Now is the clock used where the queries call NOW(), time.Now by default
+	Now func() time.Time
+}
+
+var _ database.Querier = (*Store)(nil)
+
+// New returns an empty store
+func New() *Store {
+	return &Store{Now: time.Now}
+}
+
+// InTx runs fn against a copy of the store and keeps its changes only when fn
+// returns nil. Transactions run one at a time, and writes made outside a
+// transaction wait for it to finish, so committing the copy cannot lose them.
+// Reads outside a transaction see the store as it was before the transaction.
+func (s *Store) InTx(ctx context.Context, fn func(database.Querier) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.Lock()
+	tx := &Store{
+		users:          slices.Clone(s.users),
+		feeds:          slices.Clone(s.feeds),
+		feedFollows:    slices.Clone(s.feedFollows),
+		feedFollowTags: slices.Clone(s.feedFollowTags),
+		feedFetches:    slices.Clone(s.feedFetches),
+		feedRules:      slices.Clone(s.feedRules),
+		feedScrapers:   slices.Clone(s.feedScrapers),
+		posts:          slices.Clone(s.posts),
+		authors:        slices.Clone(s.authors),
+		postAuthors:    slices.Clone(s.postAuthors),
+		postCategories: slices.Clone(s.postCategories),
+		postStars:      slices.Clone(s.postStars),
+		postReads:      slices.Clone(s.postReads),
+		Now:            s.Now,
+	}
+	s.mu.Unlock()
+
+	err := fn(tx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = tx.users
+	s.feeds = tx.feeds
+	s.feedFollows = tx.feedFollows
+	s.feedFollowTags = tx.feedFollowTags
+	s.feedFetches = tx.feedFetches
+	s.feedRules = tx.feedRules
+	s.feedScrapers = tx.feedScrapers
+	s.posts = tx.posts
+	s.authors = tx.authors
+	s.postAuthors = tx.postAuthors
+	s.postCategories = tx.postCategories
+	s.postStars = tx.postStars
+	s.postReads = tx.postReads
+	return nil
+}
+
+// lockWrite locks the store for a query that changes it, waiting for any
+// transaction to commit or roll back first
+func (s *Store) lockWrite() {
+	s.txMu.Lock()
+	s.mu.Lock()
+}
+
+func (s *Store) unlockWrite() {
+	s.mu.Unlock()
+	s.txMu.Unlock()
+}
+
+func (s *Store) now() time.Time {
+	return s.Now().UTC()
+}
+
+// conflict is the error for a row that breaks a unique constraint
+func conflict(constraint string) error {
+	return fmt.Errorf("duplicate key value violates unique constraint %q", constraint)
+}
+
+// missing is the error for a row that references one that does not exist
+func missing(constraint string) error {
+	return fmt.Errorf("insert or update violates foreign key constraint %q", constraint)
+}
+
+// referenced is the error for deleting a row that is still referenced
+func referenced(constraint string) error {
+	return fmt.Errorf("update or delete violates foreign key constraint %q", constraint)
+}
+
+func (s *Store) user(id uuid.UUID) int {
+	for i := range s.users {
+		if s.users[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Store) feed(id uuid.UUID) int {
+	for i := range s.feeds {
+		if s.feeds[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Store) feedByUrl(url sql.NullString) int {
+	if !url.Valid {
+		return -1
+	}
+	for i := range s.feeds {
+		if s.feeds[i].Url.Valid && s.feeds[i].Url.String == url.String {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Store) post(id uuid.UUID) int {
+	for i := range s.posts {
+		if s.posts[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// starred reports whether any user has starred a post
+func (s *Store) starred(postID uuid.UUID) bool {
+	for _, star := range s.postStars {
+		if star.PostID == postID {
+			return true
+		}
+	}
+	return false
+}
+
+// read reports whether a user has read a post
+func (s *Store) read(userID, postID uuid.UUID) bool {
+	for _, r := range s.postReads {
+		if r.UserID == userID && r.PostID == postID {
+			return true
+		}
+	}
+	return false
+}
+
+// followed reports whether anyone follows feedID
+func (s *Store) followed(feedID uuid.UUID) bool {
+	return slices.ContainsFunc(s.feedFollows, func(f database.FeedFollow) bool { return f.FeedID == feedID })
+}
+
+// follows reports whether userID follows feedID
+func (s *Store) follows(userID, feedID uuid.UUID) bool {
+	return s.feedFollow(userID, feedID) >= 0
+}
+
+func (s *Store) feedFollow(userID, feedID uuid.UUID) int {
+	for i, follow := range s.feedFollows {
+		if follow.UserID == userID && follow.FeedID == feedID {
+			return i
+		}
+	}
+	return -1
+}
+
+// feedTitle is what a user calls a feed: their own title for it when they
+// follow it and gave it one, or else the feed's name
+func (s *Store) feedTitle(userID, feedID uuid.UUID) sql.NullString {
+	if i := s.feedFollow(userID, feedID); i >= 0 && s.feedFollows[i].Title.Valid {
+		return s.feedFollows[i].Title
+	}
+	return s.feeds[s.feed(feedID)].Name
+}
+
+// tagged reports whether the follow has the tag
+func (s *Store) tagged(feedFollowID uuid.UUID, tag string) bool {
+	for _, t := range s.feedFollowTags {
+		if t.FeedFollowID == feedFollowID && t.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// dropFollowTags removes the tags of follows that no longer exist, as the cascade would
+func (s *Store) dropFollowTags() {
+	s.feedFollowTags = filter(s.feedFollowTags, func(t *database.FeedFollowTag) bool {
+		return slices.ContainsFunc(s.feedFollows, func(f database.FeedFollow) bool { return f.ID == t.FeedFollowID })
+	})
+}
+
+// deleteFeeds removes the feeds keep rejects along with the rows that cascade from them.
+// Posts do not cascade, so it fails without deleting anything while a feed still has posts.
+func (s *Store) deleteFeeds(keep func(*database.Feed) bool) error {
+	gone := make(map[uuid.UUID]bool)
+	for i := range s.feeds {
+		if !keep(&s.feeds[i]) {
+			gone[s.feeds[i].ID] = true
+		}
+	}
+	for _, post := range s.posts {
+		if gone[post.FeedID] {
+			return referenced("posts_feed_id_fkey")
+		}
+	}
+	s.feeds = filter(s.feeds, func(f *database.Feed) bool { return !gone[f.ID] })
+	s.feedFollows = filter(s.feedFollows, func(f *database.FeedFollow) bool { return !gone[f.FeedID] })
+	s.dropFollowTags()
+	s.feedFetches = filter(s.feedFetches, func(f *database.FeedFetch) bool { return !gone[f.FeedID] })
+	s.feedRules = filter(s.feedRules, func(r *database.FeedRule) bool { return !gone[r.FeedID] })
+	s.feedScrapers = filter(s.feedScrapers, func(f *database.FeedScraper) bool { return !gone[f.FeedID] })
+	return nil
+}
+
+// filter keeps the rows keep accepts, in order
+func filter[T any](rows []T, keep func(*T) bool) []T {
+	kept := rows[:0]
+	for i := range rows {
+		if keep(&rows[i]) {
+			kept = append(kept, rows[i])
+		}
+	}
+	clear(rows[len(kept):])
+	return kept
+}
+
+// nullsFirst orders a before b the way ORDER BY x ASC NULLS FIRST does
+func nullsFirst(a, b sql.NullTime) int {
+	switch {
+	case !a.Valid && !b.Valid:
+		return 0
+	case !a.Valid:
+		return -1
+	case !b.Valid:
+		return 1
+	}
+	return a.Time.Compare(b.Time)
+}
@@ -0,0 +1,78 @@
+package memstore
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/luckyhut/gator/database"
+	"slices"
+	"testing"
+	"time"
+)
+
+func createUser(ctx context.Context, q database.Querier, name string) error {
+	now := time.Now().UTC()
+	_, err := q.CreateUser(ctx, database.CreateUserParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Name:      name,
+	})
+	return err
+}
+
+func TestInTxKeepsWritesMadeOutsideIt(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	outside := make(chan error, 1)
+	err := s.InTx(ctx, func(q database.Querier) error {
+		go func() { outside <- createUser(ctx, s, "bob") }()
+		// the write outside the transaction has to wait for it to commit
+		select {
+		case err := <-outside:
+			t.Errorf("write outside the transaction finished while it ran: %v", err)
+			outside <- err
+		case <-time.After(50 * time.Millisecond):
+		}
+		return createUser(ctx, q, "alice")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = <-outside
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := s.GetUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(users)
+	if !slices.Equal(users, []string{"alice", "bob"}) {
+		t.Errorf("users = %v, want alice and bob", users)
+	}
+}
+
+func TestInTxRollsBack(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	failed := errors.New("failed")
+	err := s.InTx(ctx, func(q database.Querier) error {
+		err := createUser(ctx, q, "alice")
+		if err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("err = %v, want %v", err, failed)
+	}
+	users, err := s.GetUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 0 {
+		t.Errorf("users = %v after a rolled back transaction", users)
+	}
+}
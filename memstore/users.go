@@ -0,0 +1,70 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"github.com/luckyhut/gator/database"
+)
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	s.lockWrite()
+	defer s.unlockWrite()
+	for _, user := range s.users {
+		if user.ID == arg.ID {
+			return database.User{}, conflict("users_pkey")
+		}
+		if user.Name == arg.Name {
+			return database.User{}, conflict("users_name_key")
+		}
+	}
+	user := database.User{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		Name:      arg.Name,
+	}
+	s.users = append(s.users, user)
+	return user, nil
+}
+
+func (s *Store) GetUser(ctx context.Context, name string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.Name == name {
+			return user, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *Store) GetUsers(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for _, user := range s.users {
+		names = append(names, user.Name)
+	}
+	return names, nil
+}
+
+func (s *Store) GetUuid(ctx context.Context, name string) (database.User, error) {
+	return s.GetUser(ctx, name)
+}
+
+func (s *Store) ResetUsers(ctx context.Context) error {
+	s.lockWrite()
+	defer s.unlockWrite()
+	// feeds and feed_follows cascade from users, and feed_rules from both
+	err := s.deleteFeeds(func(f *database.Feed) bool { return !f.UserID.Valid })
+	if err != nil {
+		return err
+	}
+	s.users = nil
+	s.feedFollows = nil
+	s.feedFollowTags = nil
+	s.feedRules = nil
+	s.postStars = nil
+	s.postReads = nil
+	return nil
+}
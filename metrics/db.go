@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"github.com/luckyhut/gator/database"
+	"regexp"
+	"time"
+)
+
+// sqlc starts every query with a "-- name: QueryName :kind" comment
+var queryName = regexp.MustCompile(`^-- name: (\w+)`)
+
+// DB wraps a database handle so the time taken by every query is recorded in DBQueryDuration
+func DB(db database.DBTX) database.DBTX {
+	return &timedDB{db: db}
+}
+
+type timedDB struct {
+	db database.DBTX
+}
+
+func observe(query string, started time.Time) {
+	name := "unknown"
+	if m := queryName.FindStringSubmatch(query); m != nil {
+		name = m[1]
+	}
+	DBQueryDuration.Observe(time.Since(started).Seconds(), name)
+}
+
+func (t *timedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer observe(query, time.Now())
+	return t.db.ExecContext(ctx, query, args...)
+}
+
+func (t *timedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return t.db.PrepareContext(ctx, query)
+}
+
+func (t *timedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer observe(query, time.Now())
+	return t.db.QueryContext(ctx, query, args...)
+}
+
+func (t *timedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer observe(query, time.Now())
+	return t.db.QueryRowContext(ctx, query, args...)
+}
@@ -0,0 +1,24 @@
+package metrics
+
+// buckets in seconds for feed fetches, which range from a fast local file to a slow web server
+var fetchBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// buckets in seconds for database queries
+var queryBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 5}
+
+var (
+	FeedFetches = NewCounter("gator_feed_fetches_total",
+		"Feed fetch attempts by result and HTTP status code.", "result", "code")
+	FetchDuration = NewHistogram("gator_feed_fetch_duration_seconds",
+		"Time taken to fetch and store a feed, by feed kind.", fetchBuckets, "kind")
+	PostsIngested = NewCounter("gator_posts_ingested_total",
+		"Items seen while storing feeds, by what happened to them.", "outcome")
+	PostsPruned = NewCounter("gator_posts_pruned_total",
+		"Posts deleted by retention limits and orphaned feed cleanup.")
+	ParseErrors = NewCounter("gator_feed_parse_errors_total",
+		"Feeds that could not be parsed, by detected format.", "format")
+	QueueLag = NewGaugeFunc("gator_feed_queue_lag_seconds",
+		"Seconds since the feed that has waited longest was last fetched.")
+	DBQueryDuration = NewHistogram("gator_db_query_duration_seconds",
+		"Database query latency by sqlc query name.", queryBuckets, "query")
+)
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"sync"
+)
+
+// registry holds gator's own metrics, so /metrics serves only those
+var registry = prometheus.NewRegistry()
+
+// Handler serves every registered metric in the Prometheus text exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Counter is a value that only goes up, split into series by label values
+type Counter struct {
+	vec *prometheus.CounterVec
+}
+
+func NewCounter(name, help string, labels ...string) *Counter {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)
+	registry.MustRegister(vec)
+	return &Counter{vec: vec}
+}
+
+// Add adds v to the series for the given label values, which must match the counter's labels
+func (c *Counter) Add(v float64, values ...string) {
+	c.vec.WithLabelValues(values...).Add(v)
+}
+
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Histogram counts observations into cumulative buckets, split into series by label values
+type Histogram struct {
+	vec *prometheus.HistogramVec
+}
+
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labels)
+	registry.MustRegister(vec)
+	return &Histogram{vec: vec}
+}
+
+// Observe records v in the series for the given label values
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.vec.WithLabelValues(values...).Observe(v)
+}
+
+// GaugeFunc is a gauge whose value is worked out each time metrics are served
+type GaugeFunc struct {
+	desc *prometheus.Desc
+
+	mu sync.Mutex
+	fn func() (float64, error)
+}
+
+func NewGaugeFunc(name, help string) *GaugeFunc {
+	g := &GaugeFunc{desc: prometheus.NewDesc(name, help, nil, nil)}
+	registry.MustRegister(g)
+	return g
+}
+
+// Set sets the function that reports the gauge's value. Until it is set,
+// or when it fails, the gauge is left out of the output.
+func (g *GaugeFunc) Set(fn func() (float64, error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.fn = fn
+}
+
+func (g *GaugeFunc) Describe(ch chan<- *prometheus.Desc) {
+	ch <- g.desc
+}
+
+func (g *GaugeFunc) Collect(ch chan<- prometheus.Metric) {
+	g.mu.Lock()
+	fn := g.fn
+	g.mu.Unlock()
+	if fn == nil {
+		return
+	}
+	v, err := fn()
+	if err != nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(g.desc, prometheus.GaugeValue, v)
+}
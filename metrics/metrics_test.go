@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Result().Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestHandler(t *testing.T) {
+	counter := NewCounter("test_things_total", "Things counted.", "kind")
+	counter.Inc("a\"b")
+	counter.Add(2, "c")
+	histogram := NewHistogram("test_wait_seconds", "Time waited.", []float64{1, 5}, "kind")
+	histogram.Observe(0.5, "x")
+	histogram.Observe(3, "x")
+	gauge := NewGaugeFunc("test_lag_seconds", "Lag.")
+
+	out := scrape(t)
+	for _, want := range []string{
+		"# HELP test_things_total Things counted.\n# TYPE test_things_total counter\n",
+		`test_things_total{kind="a\"b"} 1` + "\n",
+		`test_things_total{kind="c"} 2` + "\n",
+		"# TYPE test_wait_seconds histogram\n",
+		`test_wait_seconds_bucket{kind="x",le="1"} 1` + "\n",
+		`test_wait_seconds_bucket{kind="x",le="5"} 2` + "\n",
+		`test_wait_seconds_bucket{kind="x",le="+Inf"} 2` + "\n",
+		`test_wait_seconds_sum{kind="x"} 3.5` + "\n",
+		`test_wait_seconds_count{kind="x"} 2` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "test_lag_seconds") {
+		t.Errorf("gauge without a function was served:\n%s", out)
+	}
+
+	gauge.Set(func() (float64, error) { return 42, nil })
+	if out := scrape(t); !strings.Contains(out, "# TYPE test_lag_seconds gauge\ntest_lag_seconds 42\n") {
+		t.Errorf("gauge missing from output:\n%s", out)
+	}
+	gauge.Set(func() (float64, error) { return 0, errors.New("no database") })
+	if out := scrape(t); strings.Contains(out, "test_lag_seconds") {
+		t.Errorf("failing gauge was served:\n%s", out)
+	}
+}
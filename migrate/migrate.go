@@ -0,0 +1,303 @@
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migration is one numbered file of goose annotated sql
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration and when it was applied, if it has been
+type Status struct {
+	Migration
+	AppliedAt sql.NullTime
+}
+
+// ErrNoMigration is returned by Down when there is nothing to roll back
+var ErrNoMigration = errors.New("No migrations have been applied")
+
+// the table goose keeps its history in, shared so databases migrated with goose keep working
+const versionTable = "goose_db_version"
+
+// Load reads the migrations in fsys, which are named like 001_users.sql, ordered by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	for _, name := range names {
+		prefix, _, found := strings.Cut(name, "_")
+		if !found {
+			return nil, fmt.Errorf("Migration %s is not named <version>_<name>.sql", name)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("Migration %s is not named <version>_<name>.sql", name)
+		}
+		body, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		m, err := parse(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("Migration %s: %w", name, err)
+		}
+		m.Version = version
+		m.Name = path.Base(name)
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("Migrations %s and %s have the same version", migrations[i-1].Name, migrations[i].Name)
+		}
+	}
+	return migrations, nil
+}
+
+// parse splits a migration into its -- +goose Up and -- +goose Down sections
+func parse(body string) (Migration, error) {
+	var m Migration
+	var up, down strings.Builder
+	var section *strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch strings.TrimSpace(line) {
+		case "-- +goose Up":
+			section = &up
+			continue
+		case "-- +goose Down":
+			section = &down
+			continue
+		}
+		if section == nil {
+			continue
+		}
+		section.WriteString(line)
+		section.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return m, err
+	}
+	if strings.TrimSpace(up.String()) == "" {
+		return m, errors.New("No -- +goose Up section")
+	}
+	m.Up = up.String()
+	m.Down = down.String()
+	return m, nil
+}
+
+// Latest is the version the newest migration brings the schema to
+func Latest(migrations []Migration) int64 {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// Version returns the version the database is at, 0 when nothing has been applied
+func Version(ctx context.Context, db *sql.DB) (int64, error) {
+	var table sql.NullString
+	err := db.QueryRowContext(ctx, "SELECT to_regclass($1)::text", versionTable).Scan(&table)
+	if err != nil {
+		return 0, err
+	}
+	if !table.Valid {
+		return 0, nil
+	}
+	applied, err := history(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Check returns an error telling the user what to do when the database is not at the latest version
+func Check(ctx context.Context, db *sql.DB, migrations []Migration) error {
+	version, err := Version(ctx, db)
+	if err != nil {
+		return fmt.Errorf("Error checking the database schema version: %w", err)
+	}
+	latest := Latest(migrations)
+	if version < latest {
+		return fmt.Errorf("The database schema is at version %d but this gator needs version %d, run gator migrate up", version, latest)
+	}
+	if version > latest {
+		return fmt.Errorf("The database schema is at version %d, newer than the version %d this gator knows, upgrade gator", version, latest)
+	}
+	return nil
+}
+
+// Up applies every migration that has not been applied, in order, and returns the ones it applied
+func Up(ctx context.Context, db *sql.DB, migrations []Migration) ([]Migration, error) {
+	err := ensureTable(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := history(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		err := run(ctx, db, m.Version, m.Up, true)
+		if err != nil {
+			return done, fmt.Errorf("Error applying %s: %w", m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Down rolls back the most recently applied migration and returns it
+func Down(ctx context.Context, db *sql.DB, migrations []Migration) (Migration, error) {
+	version, err := Version(ctx, db)
+	if err != nil {
+		return Migration{}, err
+	}
+	if version == 0 {
+		return Migration{}, ErrNoMigration
+	}
+	for _, m := range migrations {
+		if m.Version != version {
+			continue
+		}
+		err := run(ctx, db, m.Version, m.Down, false)
+		if err != nil {
+			return m, fmt.Errorf("Error rolling back %s: %w", m.Name, err)
+		}
+		return m, nil
+	}
+	return Migration{}, fmt.Errorf("The database is at version %d, which this gator has no migration for", version)
+}
+
+// GetStatus lists every migration with when it was applied
+func GetStatus(ctx context.Context, db *sql.DB, migrations []Migration) ([]Status, error) {
+	version, err := Version(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var applied map[int64]time.Time
+	if version > 0 {
+		applied, err = history(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+	}
+	statuses := make([]Status, 0, len(migrations))
+	for _, m := range migrations {
+		status := Status{Migration: m}
+		if at, ok := applied[m.Version]; ok {
+			status.AppliedAt = sql.NullTime{Time: at, Valid: true}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// ensureTable creates the version table the way goose does, starting at version 0
+func ensureTable(ctx context.Context, db *sql.DB) error {
+	var table sql.NullString
+	err := db.QueryRowContext(ctx, "SELECT to_regclass($1)::text", versionTable).Scan(&table)
+	if err != nil || table.Valid {
+		return err
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, `CREATE TABLE `+versionTable+` (
+    id serial NOT NULL,
+    version_id bigint NOT NULL,
+    is_applied boolean NOT NULL,
+    tstamp timestamp NULL default now(),
+    PRIMARY KEY(id)
+)`)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO "+versionTable+" (version_id, is_applied) VALUES (0, true)")
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// history returns each applied version with when it was applied. Like goose it reads the
+// log newest first, so a version rolled back and not reapplied does not count.
+func history(ctx context.Context, db *sql.DB) (map[int64]time.Time, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version_id, is_applied, tstamp FROM "+versionTable+" ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	seen := make(map[int64]bool)
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var tstamp sql.NullTime
+		if err := rows.Scan(&version, &isApplied, &tstamp); err != nil {
+			return nil, err
+		}
+		if seen[version] {
+			continue
+		}
+		seen[version] = true
+		if isApplied && version > 0 {
+			applied[version] = tstamp.Time
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// run executes one side of a migration and records it in a single transaction
+func run(ctx context.Context, db *sql.DB, version int64, statements string, up bool) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	// without arguments lib/pq sends this as a simple query, which may hold several statements
+	if strings.TrimSpace(statements) != "" {
+		_, err = tx.ExecContext(ctx, statements)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO "+versionTable+" (version_id, is_applied) VALUES ($1, $2)", version, up)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,64 @@
+package migrate
+
+import (
+	"github.com/luckyhut/gator/sql/schema"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_feeds.sql": {Data: []byte("-- +goose Up\nCREATE TABLE feeds (id int);\n\n-- +goose Down\nDROP TABLE feeds;\n")},
+		"001_users.sql": {Data: []byte("-- a comment before the sections\n-- +goose Up\nCREATE TABLE users (id int);\n")},
+		"README.md":     {Data: []byte("not a migration")},
+	}
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].Version != 2 {
+		t.Fatalf("got %+v, want versions 1 and 2", migrations)
+	}
+	if migrations[0].Name != "001_users.sql" || migrations[0].Up != "CREATE TABLE users (id int);\n" || migrations[0].Down != "" {
+		t.Errorf("first migration = %+v", migrations[0])
+	}
+	if !strings.Contains(migrations[1].Up, "CREATE TABLE feeds") || strings.TrimSpace(migrations[1].Down) != "DROP TABLE feeds;" {
+		t.Errorf("second migration = %+v", migrations[1])
+	}
+	if Latest(migrations) != 2 {
+		t.Errorf("Latest = %d, want 2", Latest(migrations))
+	}
+}
+
+func TestLoadRejectsBadMigrations(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"no version":   {"users.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")}},
+		"zero version": {"000_users.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")}},
+		"no up":        {"001_users.sql": {Data: []byte("-- +goose Down\nSELECT 1;\n")}},
+		"same version": {
+			"001_users.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+			"1_feeds.sql":   {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+		},
+	}
+	for name, fsys := range tests {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: Load succeeded", name)
+		}
+	}
+}
+
+func TestLoadSchema(t *testing.T) {
+	migrations, err := Load(schema.Migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %s has version %d, want %d", m.Name, m.Version, i+1)
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			t.Errorf("migration %s has no down section", m.Name)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats Parse can detect
+const (
+	FormatRSS  = "rss"
+	FormatRDF  = "rdf"
+	FormatAtom = "atom"
+)
+
+// Parse detects the format of a feed document from its root element and
+// decodes it into an RSSFeed. It also returns the format it found.
+func Parse(body []byte) (*RSSFeed, string, error) {
+	format, err := detect(body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var feed *RSSFeed
+	switch format {
+	case FormatRSS:
+		feed = &RSSFeed{}
+		err = xml.Unmarshal(body, feed)
+	case FormatRDF:
+		feed, err = parseRDF(body)
+	case FormatAtom:
+		feed, err = parseAtom(body)
+	}
+	if err != nil {
+		return nil, format, fmt.Errorf("Error unmarshaling %s data: %w", format, err)
+	}
+	return feed, format, nil
+}
+
+func detect(body []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return "", errors.New("Document is empty")
+		}
+		if err != nil {
+			return "", errors.New("Document is not xml")
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "rss":
+			return FormatRSS, nil
+		case "RDF":
+			return FormatRDF, nil
+		case "feed":
+			return FormatAtom, nil
+		}
+		return "", fmt.Errorf("Unknown feed format <%s>", start.Name.Local)
+	}
+}
+
+// RSS 1.0 keeps its items next to the channel instead of inside it
+type rdfFeed struct {
+	Channel struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		Description string `xml:"description"`
+	} `xml:"channel"`
+	Item []struct {
+		RSSItem
+		Date    string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+		Subject []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+	} `xml:"item"`
+}
+
+func parseRDF(body []byte) (*RSSFeed, error) {
+	var rdf rdfFeed
+	err := xml.Unmarshal(body, &rdf)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &RSSFeed{}
+	feed.Channel.Title = rdf.Channel.Title
+	feed.Channel.Link = []string{rdf.Channel.Link}
+	feed.Channel.Description = rdf.Channel.Description
+	for _, item := range rdf.Item {
+		converted := item.RSSItem
+		if converted.PubDate == "" {
+			converted.PubDate = item.Date
+		}
+		converted.Category = append(converted.Category, item.Subject...)
+		feed.Channel.Item = append(feed.Channel.Item, converted)
+	}
+	return feed, nil
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+// atomText is an Atom text construct. Text and html bodies are character data,
+// escaped or in CDATA, while xhtml bodies are markup wrapped in a div.
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+	Div   struct {
+		Inner string `xml:",innerxml"`
+	} `xml:"http://www.w3.org/1999/xhtml div"`
+}
+
+// Body is the element's text, or its markup without the wrapping div for xhtml
+func (t *atomText) Body() string {
+	if t.Type != "xhtml" {
+		return strings.TrimSpace(t.Text)
+	}
+	if t.Div.Inner != "" {
+		return strings.TrimSpace(t.Div.Inner)
+	}
+	return strings.TrimSpace(t.Inner)
+}
+
+type atomFeed struct {
+	Title     string     `xml:"title"`
+	Subtitle  string     `xml:"subtitle"`
+	Link      []atomLink `xml:"link"`
+	Generator string     `xml:"generator"`
+	Logo      string     `xml:"logo"`
+	Icon      string     `xml:"icon"`
+	Lang      string     `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Entry     []struct {
+		ID        string     `xml:"id"`
+		Title     string     `xml:"title"`
+		Link      []atomLink `xml:"link"`
+		Summary   atomText   `xml:"summary"`
+		Content   atomText   `xml:"content"`
+		Published string     `xml:"published"`
+		Updated   string     `xml:"updated"`
+		Author    []struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Category []struct {
+			Term string `xml:"term,attr"`
+		} `xml:"category"`
+	} `xml:"entry"`
+}
+
+func parseAtom(body []byte) (*RSSFeed, error) {
+	var atom atomFeed
+	err := xml.Unmarshal(body, &atom)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &RSSFeed{}
+	feed.Channel.Title = atom.Title
+	feed.Channel.Link = []string{alternateLink(atom.Link)}
+	feed.Channel.Description = atom.Subtitle
+	feed.Channel.Language = atom.Lang
+	feed.Channel.Generator = strings.TrimSpace(atom.Generator)
+	feed.Channel.Image.URL = atom.Logo
+	if feed.Channel.Image.URL == "" {
+		feed.Channel.Image.URL = atom.Icon
+	}
+
+	for _, entry := range atom.Entry {
+		item := RSSItem{
+			Title:       entry.Title,
+			Link:        alternateLink(entry.Link),
+			Description: entry.Summary.Body(),
+			PubDate:     entry.Published,
+			GUID:        entry.ID,
+		}
+		if item.Description == "" {
+			item.Description = entry.Content.Body()
+		}
+		if item.PubDate == "" {
+			item.PubDate = entry.Updated
+		}
+		for _, author := range entry.Author {
+			item.Creator = append(item.Creator, author.Name)
+		}
+		for _, category := range entry.Category {
+			item.Category = append(item.Category, category.Term)
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+	return feed, nil
+}
+
+// alternateLink picks the link to the html page out of an Atom element's links
+func alternateLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	return ""
+}
@@ -0,0 +1,174 @@
+package rss
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAtomBodies(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"text", `<summary>plain &amp; simple</summary>`, "plain & simple"},
+		{"cdata", `<summary type="html"><![CDATA[<p>hi & bye</p>]]></summary>`, "<p>hi & bye</p>"},
+		{"escaped html", `<summary type="html">&lt;p&gt;hi &amp;amp; bye&lt;/p&gt;</summary>`, "<p>hi &amp; bye</p>"},
+		{"xhtml", `<summary type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>hi</p></div></summary>`, "<p>hi</p>"},
+		{"content when no summary", `<content type="html">&lt;b&gt;body&lt;/b&gt;</content>`, "<b>body</b>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := `<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>Example</title>
+	<entry>
+		<id>urn:1</id>
+		<title>Entry</title>
+		<link href="https://example.com/1"/>
+		` + tt.body + `
+	</entry>
+</feed>`
+			feed, format, err := Parse([]byte(doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != FormatAtom {
+				t.Errorf("format = %s, want %s", format, FormatAtom)
+			}
+			if len(feed.Channel.Item) != 1 {
+				t.Fatalf("got %d items, want 1", len(feed.Channel.Item))
+			}
+			if got := feed.Channel.Item[0].Description; got != tt.want {
+				t.Errorf("description = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRSS(t *testing.T) {
+	doc := `<?xml version="1.0"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel>
+	<title>Example</title>
+	<atom:link href="https://example.com/feed.xml" rel="self"/>
+	<link>https://example.com/</link>
+	<item>
+		<title>Post</title>
+		<link>https://example.com/post</link>
+		<pubDate>Mon, 02 Jun 2025 10:00:00 +0000</pubDate>
+		<category> Go </category>
+		<category>go</category>
+		<author>jane@example.com (Jane Doe)</author>
+		<dc:creator>Jane Doe</dc:creator>
+	</item>
+</channel>
+</rss>`
+	feed, format, err := Parse([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != FormatRSS {
+		t.Errorf("format = %s, want %s", format, FormatRSS)
+	}
+	if got := feed.SiteLink(); got != "https://example.com/" {
+		t.Errorf("site link = %q, want https://example.com/", got)
+	}
+	if len(feed.Channel.Item) != 1 {
+		t.Fatalf("got %d items, want 1", len(feed.Channel.Item))
+	}
+	item := feed.Channel.Item[0]
+	if got := item.Categories(); len(got) != 1 || got[0] != "Go" {
+		t.Errorf("categories = %q, want [Go]", got)
+	}
+	if got := item.Authors(); len(got) != 1 || got[0] != "Jane Doe" {
+		t.Errorf("authors = %q, want [Jane Doe]", got)
+	}
+}
+
+func TestParseRDF(t *testing.T) {
+	doc := `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+	<channel>
+		<title>Example</title>
+		<link>https://example.com/</link>
+		<description>An RSS 1.0 feed</description>
+	</channel>
+	<item>
+		<title>Post</title>
+		<link>https://example.com/post</link>
+		<dc:date>2025-06-02T10:00:00Z</dc:date>
+		<dc:subject>go</dc:subject>
+	</item>
+</rdf:RDF>`
+	feed, format, err := Parse([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != FormatRDF {
+		t.Errorf("format = %s, want %s", format, FormatRDF)
+	}
+	if feed.Channel.Title != "Example" || feed.SiteLink() != "https://example.com/" {
+		t.Errorf("channel = %q %q", feed.Channel.Title, feed.SiteLink())
+	}
+	if len(feed.Channel.Item) != 1 {
+		t.Fatalf("got %d items, want 1", len(feed.Channel.Item))
+	}
+	item := feed.Channel.Item[0]
+	if item.Link != "https://example.com/post" || item.PubDate != "2025-06-02T10:00:00Z" {
+		t.Errorf("item link %q, date %q", item.Link, item.PubDate)
+	}
+	if len(item.Category) != 1 || item.Category[0] != "go" {
+		t.Errorf("categories = %q, want [go]", item.Category)
+	}
+}
+
+func TestParseRejectsUnknownDocuments(t *testing.T) {
+	for _, doc := range []string{"", "not xml <", `<html><body/></html>`} {
+		if _, _, err := Parse([]byte(doc)); err == nil {
+			t.Errorf("Parse(%q) succeeded", doc)
+		}
+	}
+}
+
+func TestAuthorName(t *testing.T) {
+	tests := map[string]string{
+		"jane@example.com (Jane Doe)":   "Jane Doe",
+		`"Jane Doe" <jane@example.com>`: "Jane Doe",
+		"  Jane   Doe ":                 "Jane Doe",
+		"jane@example.com":              "jane@example.com",
+	}
+	for raw, want := range tests {
+		if got := AuthorName(raw); got != want {
+			t.Errorf("AuthorName(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	want := time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC)
+	for _, value := range []string{
+		"Mon, 02 Jun 2025 10:00:00 +0000",
+		"Mon, 02 Jun 2025 12:00:00 +0200",
+		"Mon, 02 Jun 2025 10:00:00 GMT",
+		"Mon, 2 Jun 2025 10:00:00 +0000",
+		"2025-06-02T10:00:00Z",
+		"2025-06-02T06:00:00-04:00",
+		" 2025-06-02T10:00:00 ",
+		"2025-06-02 10:00:00",
+	} {
+		got, err := ParseDate(value)
+		if err != nil {
+			t.Errorf("ParseDate(%q): %v", value, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("ParseDate(%q) = %v, want %v", value, got, want)
+		}
+	}
+	for _, value := range []string{"", "yesterday", "02/06/2025"} {
+		if _, err := ParseDate(value); err == nil {
+			t.Errorf("ParseDate(%q) succeeded", value)
+		}
+	}
+}
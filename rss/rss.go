@@ -1,17 +1,114 @@
 package rss
 
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
+		Title string `xml:"title"`
+		// a slice because <atom:link rel="self"/> also matches and is usually empty
+		Link        []string `xml:"link"`
+		Description string   `xml:"description"`
+		Language    string   `xml:"language"`
+		Generator   string   `xml:"generator"`
+		Image       struct {
+			URL string `xml:"url"`
+		} `xml:"image"`
+		Item []RSSItem `xml:"item"`
 	} `xml:"channel"`
 }
 
+// SiteLink returns the channel's link to the site the feed belongs to
+func (feed *RSSFeed) SiteLink() string {
+	for _, link := range feed.Channel.Link {
+		if link = strings.TrimSpace(link); link != "" {
+			return link
+		}
+	}
+	return ""
+}
+
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	PubDate     string   `xml:"pubDate"`
+	GUID        string   `xml:"guid"`
+	Category    []string `xml:"category"`
+	Author      string   `xml:"author"`
+	Creator     []string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+}
+
+// Categories returns the item's categories trimmed and without duplicates
+func (item *RSSItem) Categories() []string {
+	var categories []string
+	seen := make(map[string]bool)
+	for _, c := range item.Category {
+		c = strings.Join(strings.Fields(c), " ")
+		if c == "" || seen[strings.ToLower(c)] {
+			continue
+		}
+		seen[strings.ToLower(c)] = true
+		categories = append(categories, c)
+	}
+	return categories
+}
+
+// Authors returns the display names found in <author> and <dc:creator>
+func (item *RSSItem) Authors() []string {
+	var authors []string
+	seen := make(map[string]bool)
+	for _, raw := range append([]string{item.Author}, item.Creator...) {
+		name := AuthorName(raw)
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		authors = append(authors, name)
+	}
+	return authors
+}
+
+// AuthorName pulls a display name out of the forms feeds use for authors:
+// "jane@example.com (Jane Doe)", "Jane Doe <jane@example.com>" or a bare name
+func AuthorName(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if open := strings.Index(raw, "("); open > 0 && strings.HasSuffix(raw, ")") {
+		raw = raw[open+1 : len(raw)-1]
+	} else if open := strings.Index(raw, "<"); open > 0 && strings.HasSuffix(raw, ">") {
+		raw = raw[:open]
+	}
+	raw = strings.Trim(raw, "\"' ")
+	return strings.Join(strings.Fields(raw), " ")
+}
+
+// date layouts seen in pubDate, dc:date and Atom's published, most common first
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	"2006-01-02T15:04:05",
+	time.DateTime,
+	time.DateOnly,
+}
+
+// ParseDate reads the date of an item in any of the layouts feeds use. Dates without
+// a zone are taken as UTC.
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, errors.New("Unknown date format")
 }
@@ -0,0 +1,125 @@
+package rules
+
+import (
+	"fmt"
+	"github.com/luckyhut/gator/rss"
+	"regexp"
+	"strings"
+)
+
+// Actions a rule can take on an item
+const (
+	Drop    = "drop"    // drop the item when the field matches the pattern
+	Keep    = "keep"    // drop the item unless the field matches the pattern
+	Replace = "replace" // replace every match of the pattern in the field
+	Prefix  = "prefix"  // put the pattern text in front of the field
+)
+
+// Fields of an item a rule can look at
+var fields = []string{"title", "link", "description"}
+
+type Rule struct {
+	Action      string
+	Field       string
+	Pattern     string
+	Replacement string
+}
+
+type compiledRule struct {
+	Rule
+	re *regexp.Regexp
+}
+
+// Pipeline is an ordered list of rules ready to be applied to items
+type Pipeline struct {
+	rules []compiledRule
+}
+
+// Validate reports whether a rule could be compiled into a pipeline
+func (r Rule) Validate() error {
+	_, err := compile(r)
+	return err
+}
+
+func (r Rule) String() string {
+	switch r.Action {
+	case Replace:
+		return fmt.Sprintf("%s %s %q with %q", r.Action, r.Field, r.Pattern, r.Replacement)
+	default:
+		return fmt.Sprintf("%s %s %q", r.Action, r.Field, r.Pattern)
+	}
+}
+
+func compile(r Rule) (compiledRule, error) {
+	if !validField(r.Field) {
+		return compiledRule{}, fmt.Errorf("Unknown field %s, must be one of %s", r.Field, strings.Join(fields, ", "))
+	}
+	switch r.Action {
+	case Drop, Keep, Replace:
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return compiledRule{}, fmt.Errorf("Invalid pattern %q: %v", r.Pattern, err)
+		}
+		return compiledRule{Rule: r, re: re}, nil
+	case Prefix:
+		return compiledRule{Rule: r}, nil
+	}
+	return compiledRule{}, fmt.Errorf("Unknown action %s, must be one of drop, keep, replace, prefix", r.Action)
+}
+
+func validField(field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Compile checks every rule and returns a pipeline that applies them in order
+func Compile(rules []Rule) (*Pipeline, error) {
+	p := &Pipeline{}
+	for i, r := range rules {
+		c, err := compile(r)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		p.rules = append(p.rules, c)
+	}
+	return p, nil
+}
+
+// Apply runs the pipeline over item, changing it in place. It returns the
+// index of the rule that dropped the item, or -1 if the item is kept.
+func (p *Pipeline) Apply(item *rss.RSSItem) int {
+	for i, r := range p.rules {
+		value := field(item, r.Field)
+		switch r.Action {
+		case Drop:
+			if r.re.MatchString(*value) {
+				return i
+			}
+		case Keep:
+			if !r.re.MatchString(*value) {
+				return i
+			}
+		case Replace:
+			*value = r.re.ReplaceAllString(*value, r.Replacement)
+		case Prefix:
+			if !strings.HasPrefix(*value, r.Pattern) {
+				*value = r.Pattern + *value
+			}
+		}
+	}
+	return -1
+}
+
+func field(item *rss.RSSItem, name string) *string {
+	switch name {
+	case "link":
+		return &item.Link
+	case "description":
+		return &item.Description
+	}
+	return &item.Title
+}
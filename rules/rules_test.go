@@ -0,0 +1,78 @@
+package rules
+
+import (
+	"github.com/luckyhut/gator/rss"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		rule Rule
+		ok   bool
+	}{
+		{Rule{Action: Drop, Field: "title", Pattern: "(?i)sponsored"}, true},
+		{Rule{Action: Prefix, Field: "title", Pattern: "[go] "}, true},
+		{Rule{Action: Drop, Field: "title", Pattern: "("}, false},
+		{Rule{Action: Drop, Field: "author", Pattern: "x"}, false},
+		{Rule{Action: "delete", Field: "title", Pattern: "x"}, false},
+	}
+	for _, tt := range tests {
+		err := tt.rule.Validate()
+		if (err == nil) != tt.ok {
+			t.Errorf("Validate(%s) = %v, want ok %v", tt.rule, err, tt.ok)
+		}
+	}
+	_, err := Compile([]Rule{tests[0].rule, tests[2].rule})
+	if err == nil {
+		t.Error("Compile accepted an invalid rule")
+	}
+}
+
+func TestApply(t *testing.T) {
+	p, err := Compile([]Rule{
+		{Action: Drop, Field: "title", Pattern: "(?i)sponsored"},
+		{Action: Keep, Field: "link", Pattern: "^https://"},
+		{Action: Replace, Field: "link", Pattern: `/amp/`, Replacement: "/"},
+		{Action: Replace, Field: "description", Pattern: `\s*Read more\.$`},
+		{Action: Prefix, Field: "title", Pattern: "[blog] "},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		item    rss.RSSItem
+		dropped int
+		want    rss.RSSItem
+	}{
+		{
+			rss.RSSItem{Title: "Sponsored: buy this", Link: "https://example.com/ad"},
+			0,
+			rss.RSSItem{Title: "Sponsored: buy this", Link: "https://example.com/ad"},
+		},
+		{
+			rss.RSSItem{Title: "Plain", Link: "http://example.com/post"},
+			1,
+			rss.RSSItem{Title: "Plain", Link: "http://example.com/post"},
+		},
+		{
+			rss.RSSItem{Title: "Post", Link: "https://example.com/amp/post", Description: "Body. Read more."},
+			-1,
+			rss.RSSItem{Title: "[blog] Post", Link: "https://example.com/post", Description: "Body."},
+		},
+		{
+			rss.RSSItem{Title: "[blog] Again", Link: "https://example.com/again"},
+			-1,
+			rss.RSSItem{Title: "[blog] Again", Link: "https://example.com/again"},
+		},
+	}
+	for _, tt := range tests {
+		item := tt.item
+		if got := p.Apply(&item); got != tt.dropped {
+			t.Errorf("Apply(%q) dropped by rule %d, want %d", tt.item.Title, got, tt.dropped)
+		}
+		if item.Title != tt.want.Title || item.Link != tt.want.Link || item.Description != tt.want.Description {
+			t.Errorf("Apply(%q) = %+v, want %+v", tt.item.Title, item, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package scrape
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/luckyhut/gator/rss"
+	"net/url"
+	"strings"
+)
+
+// Selectors describe where the items of an HTML page are. Item is required,
+// the others are looked up inside each item and may be left empty.
+type Selectors struct {
+	Item    string
+	Title   string
+	Link    string
+	Date    string
+	Summary string
+}
+
+// Validate checks that every selector given is valid CSS
+func (sel Selectors) Validate() error {
+	if sel.Item == "" {
+		return errors.New("An item selector is required")
+	}
+	for name, s := range map[string]string{
+		"item":    sel.Item,
+		"title":   sel.Title,
+		"link":    sel.Link,
+		"date":    sel.Date,
+		"summary": sel.Summary,
+	} {
+		if s == "" {
+			continue
+		}
+		if _, err := cascadia.ParseGroup(s); err != nil {
+			return fmt.Errorf("Invalid %s selector %q: %v", name, s, err)
+		}
+	}
+	return nil
+}
+
+// Parse turns the elements matched by sel in an HTML page into a feed.
+// Relative links are resolved against pageURL.
+func Parse(body []byte, pageURL string, sel Selectors) (*rss.RSSFeed, error) {
+	err := sel.Validate()
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid page url %s", pageURL)
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.New("Error parsing html page")
+	}
+
+	var feed rss.RSSFeed
+	feed.Channel.Title = clean(doc.Find("title").First().Text())
+	feed.Channel.Link = []string{pageURL}
+	feed.Channel.Description, _ = doc.Find(`meta[name="description"]`).Attr("content")
+	feed.Channel.Language, _ = doc.Find("html").Attr("lang")
+
+	doc.Find(sel.Item).Each(func(_ int, s *goquery.Selection) {
+		item := rss.RSSItem{
+			Title:   clean(find(s, sel.Title).Text()),
+			Link:    link(s, sel.Link, base),
+			PubDate: date(s, sel.Date),
+		}
+		if sel.Summary != "" {
+			item.Description = clean(find(s, sel.Summary).Text())
+		}
+		if item.Title == "" {
+			return
+		}
+		if item.Link == "" {
+			// posts need a unique url, so items without a link point back at the page
+			sum := sha1.Sum([]byte(item.Title))
+			item.Link = pageURL + "#" + hex.EncodeToString(sum[:6])
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	})
+	return &feed, nil
+}
+
+// find returns the first match of selector inside s, or s itself when selector is empty
+func find(s *goquery.Selection, selector string) *goquery.Selection {
+	if selector == "" {
+		return s
+	}
+	return s.Find(selector).First()
+}
+
+func link(s *goquery.Selection, selector string, base *url.URL) string {
+	el := find(s, selector)
+	href, ok := el.Attr("href")
+	if !ok {
+		href, ok = el.Find("a[href]").First().Attr("href")
+	}
+	if !ok {
+		return ""
+	}
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(ref).String()
+}
+
+func date(s *goquery.Selection, selector string) string {
+	if selector == "" {
+		return ""
+	}
+	el := find(s, selector)
+	if datetime, ok := el.Attr("datetime"); ok {
+		return strings.TrimSpace(datetime)
+	}
+	return clean(el.Text())
+}
+
+func clean(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,76 @@
+package scrape
+
+import "testing"
+
+const page = `<html lang="en">
+<head>
+	<title> Example   News </title>
+	<meta name="description" content="Latest news">
+</head>
+<body>
+	<article>
+		<h2><a href="/news/1">First  story</a></h2>
+		<time datetime="2025-06-02T10:00:00Z">June 2</time>
+		<p>Something happened.</p>
+	</article>
+	<article>
+		<h2>No link</h2>
+		<span class="date">June 3, 2025</span>
+	</article>
+	<article>
+		<p>No title</p>
+	</article>
+</body>
+</html>`
+
+func TestParse(t *testing.T) {
+	sel := Selectors{Item: "article", Title: "h2", Date: "time, .date", Summary: "p"}
+	feed, err := Parse([]byte(page), "https://example.com/news/", sel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Channel.Title != "Example News" || feed.Channel.Description != "Latest news" || feed.Channel.Language != "en" {
+		t.Errorf("channel = %q %q %q", feed.Channel.Title, feed.Channel.Description, feed.Channel.Language)
+	}
+	if len(feed.Channel.Item) != 2 {
+		t.Fatalf("got %d items, want 2 as items without a title are skipped", len(feed.Channel.Item))
+	}
+
+	first := feed.Channel.Item[0]
+	if first.Title != "First story" || first.Link != "https://example.com/news/1" {
+		t.Errorf("first item: %q %q", first.Title, first.Link)
+	}
+	if first.PubDate != "2025-06-02T10:00:00Z" || first.Description != "Something happened." {
+		t.Errorf("first item: date %q, summary %q", first.PubDate, first.Description)
+	}
+
+	second := feed.Channel.Item[1]
+	if second.PubDate != "June 3, 2025" {
+		t.Errorf("second item date = %q", second.PubDate)
+	}
+	again, err := Parse([]byte(page), "https://example.com/news/", sel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Link == "" || second.Link != again.Channel.Item[1].Link {
+		t.Errorf("an item without a link needs a stable url, got %q and %q", second.Link, again.Channel.Item[1].Link)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		sel Selectors
+		ok  bool
+	}{
+		{Selectors{Item: "article"}, true},
+		{Selectors{Item: "li.post", Title: "a", Link: "a", Date: "time", Summary: "p"}, true},
+		{Selectors{Title: "h2"}, false},
+		{Selectors{Item: "article", Title: "h2["}, false},
+	}
+	for _, tt := range tests {
+		err := tt.sel.Validate()
+		if (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v) = %v, want ok %v", tt.sel, err, tt.ok)
+		}
+	}
+}
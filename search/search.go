@@ -0,0 +1,259 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Term is one word or quoted phrase of a search
+type Term struct {
+	Words  []string // more than one for a phrase, whose words must appear in order
+	Prefix bool     // the last word also matches longer words, written word*
+	Not    bool     // posts containing the term are left out, written -word
+}
+
+// Query is a parsed search. A post matches when it matches every group,
+// and it matches a group when it matches any of the group's terms.
+type Query struct {
+	Groups [][]Term
+}
+
+// Parse reads a search like `golang "error handling" gener* OR templat* -python`.
+// Quoted words are a phrase, a trailing * makes a prefix, a leading - excludes
+// and OR between two terms matches either.
+func Parse(input string) (Query, error) {
+	var q Query
+	or := false
+	for _, token := range tokenize(input) {
+		if token == "OR" {
+			or = len(q.Groups) > 0
+			continue
+		}
+		term, ok := parseTerm(token)
+		if !ok {
+			continue
+		}
+		if or {
+			last := len(q.Groups) - 1
+			q.Groups[last] = append(q.Groups[last], term)
+		} else {
+			q.Groups = append(q.Groups, []Term{term})
+		}
+		or = false
+	}
+	if len(q.Groups) == 0 {
+		return q, errors.New("Nothing to search for")
+	}
+	for _, group := range q.Groups {
+		for _, term := range group {
+			if !term.Not {
+				return q, nil
+			}
+		}
+	}
+	return q, errors.New("A search needs at least one word that isn't excluded")
+}
+
+// tokenize splits input on spaces, keeping quoted phrases together with their quotes
+func tokenize(input string) []string {
+	var tokens []string
+	var token strings.Builder
+	quoted := false
+	for _, r := range input {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			token.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+		default:
+			token.WriteRune(r)
+		}
+	}
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+	return tokens
+}
+
+func parseTerm(token string) (Term, bool) {
+	var term Term
+	if strings.HasPrefix(token, "-") {
+		term.Not = true
+		token = token[1:]
+	}
+	token = strings.Trim(token, `"`)
+	if strings.HasSuffix(token, "*") {
+		term.Prefix = true
+	}
+	term.Words = words(token)
+	return term, len(term.Words) > 0
+}
+
+// words lowercases text and splits it into runs of letters and digits
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// TSQuery writes the query in Postgres to_tsquery syntax
+func (q Query) TSQuery() string {
+	groups := make([]string, 0, len(q.Groups))
+	for _, group := range q.Groups {
+		terms := make([]string, 0, len(group))
+		for _, term := range group {
+			terms = append(terms, term.tsquery())
+		}
+		if len(terms) == 1 {
+			groups = append(groups, terms[0])
+		} else {
+			groups = append(groups, "("+strings.Join(terms, " | ")+")")
+		}
+	}
+	return strings.Join(groups, " & ")
+}
+
+func (t Term) tsquery() string {
+	s := strings.Join(t.Words, " <-> ")
+	if t.Prefix {
+		s += ":*"
+	}
+	if t.Not {
+		if len(t.Words) > 1 {
+			s = "(" + s + ")"
+		}
+		s = "!" + s
+	}
+	return s
+}
+
+// ParseTSQuery reads back a query written by TSQuery
+func ParseTSQuery(tsquery string) (Query, error) {
+	var q Query
+	for _, group := range strings.Split(tsquery, " & ") {
+		if strings.HasPrefix(group, "(") {
+			group = strings.TrimSuffix(group[1:], ")")
+		}
+		var terms []Term
+		for _, s := range strings.Split(group, " | ") {
+			var term Term
+			if strings.HasPrefix(s, "!") {
+				term.Not = true
+				s = strings.TrimSuffix(strings.TrimPrefix(s[1:], "("), ")")
+			}
+			if strings.HasSuffix(s, ":*") {
+				term.Prefix = true
+				s = strings.TrimSuffix(s, ":*")
+			}
+			term.Words = strings.Split(s, " <-> ")
+			terms = append(terms, term)
+		}
+		q.Groups = append(q.Groups, terms)
+	}
+	if len(q.Groups) == 0 || tsquery == "" {
+		return q, errors.New("Nothing to search for")
+	}
+	return q, nil
+}
+
+// Match counts how often text matches the terms of q, or returns 0 when text doesn't
+// match q. Unlike Postgres it compares whole words, without stemming.
+func (q Query) Match(text string) int {
+	textWords := words(text)
+	hits := 0
+	for _, group := range q.Groups {
+		matched := false
+		for _, term := range group {
+			n := term.count(textWords)
+			if term.Not {
+				matched = matched || n == 0
+				continue
+			}
+			matched = matched || n > 0
+			hits += n
+		}
+		if !matched {
+			return 0
+		}
+	}
+	// a group matched only by leaving a word out still counts as a match
+	return max(hits, 1)
+}
+
+// count returns how many times the term appears in text
+func (t Term) count(text []string) int {
+	n := 0
+	for i := range text {
+		if t.matchAt(text, i) {
+			n++
+		}
+	}
+	return n
+}
+
+func (t Term) matchAt(text []string, i int) bool {
+	if i+len(t.Words) > len(text) {
+		return false
+	}
+	for j, word := range t.Words {
+		last := j == len(t.Words)-1
+		if last && t.Prefix {
+			if !strings.HasPrefix(text[i+j], word) {
+				return false
+			}
+		} else if text[i+j] != word {
+			return false
+		}
+	}
+	return true
+}
+
+// Highlight wraps the words of text that q looks for in start and stop
+func (q Query) Highlight(text, start, stop string) string {
+	var out strings.Builder
+	var word strings.Builder
+	flush := func() {
+		if word.Len() == 0 {
+			return
+		}
+		w := word.String()
+		if q.wants(strings.ToLower(w)) {
+			out.WriteString(start + w + stop)
+		} else {
+			out.WriteString(w)
+		}
+		word.Reset()
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word.WriteRune(r)
+			continue
+		}
+		flush()
+		out.WriteRune(r)
+	}
+	flush()
+	return out.String()
+}
+
+// wants reports whether word is one q searches for
+func (q Query) wants(word string) bool {
+	for _, group := range q.Groups {
+		for _, term := range group {
+			if term.Not {
+				continue
+			}
+			for i, w := range term.Words {
+				if w == word || (term.Prefix && i == len(term.Words)-1 && strings.HasPrefix(word, w)) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		tsquery string
+	}{
+		{"golang", "golang"},
+		{"Go  generics", "go & generics"},
+		{`"error handling" go`, "error <-> handling & go"},
+		{"gener* OR templat*", "(gener:* | templat:*)"},
+		{"golang -python", "golang & !python"},
+		{`golang -"hello world"`, "golang & !(hello <-> world)"},
+		{"OR golang", "golang"},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.input, err)
+			continue
+		}
+		if got := q.TSQuery(); got != tt.tsquery {
+			t.Errorf("Parse(%q).TSQuery() = %q, want %q", tt.input, got, tt.tsquery)
+		}
+		back, err := ParseTSQuery(tt.tsquery)
+		if err != nil {
+			t.Errorf("ParseTSQuery(%q): %v", tt.tsquery, err)
+			continue
+		}
+		if !reflect.DeepEqual(back, q) {
+			t.Errorf("ParseTSQuery(%q) = %+v, want %+v", tt.tsquery, back, q)
+		}
+	}
+}
+
+func TestParseRejectsEmptySearches(t *testing.T) {
+	for _, input := range []string{"", "   ", `""`, "-python", "OR"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) succeeded", input)
+		}
+	}
+	if _, err := ParseTSQuery(""); err == nil {
+		t.Error(`ParseTSQuery("") succeeded`)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		query string
+		text  string
+		want  int
+	}{
+		{"go", "Go is fun, go go", 3},
+		{"gener*", "Generics and generators", 2},
+		{`"error handling"`, "error handling in go", 1},
+		{`"error handling"`, "handling errors", 0},
+		{"go -python", "go and python", 0},
+		{"rust OR go", "go only", 1},
+		{"rust OR -go", "nothing here", 1},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := q.Match(tt.text); got != tt.want {
+			t.Errorf("%q matched %q %d times, want %d", tt.query, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	q, err := Parse("gener* -python")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := q.Highlight("Generics, not python.", "[", "]")
+	if want := "[Generics], not python."; got != want {
+		t.Errorf("Highlight = %q, want %q", got, want)
+	}
+}
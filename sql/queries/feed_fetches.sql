@@ -0,0 +1,32 @@
+-- name: CreateFeedFetch :exec
+INSERT INTO feed_fetches (id, feed_id, started_at, duration_ms, http_status, bytes, new_items, updated_items, skipped_items, error)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10
+);
+
+-- name: GetFeedFetches :many
+SELECT *
+FROM feed_fetches
+WHERE feed_id = $1
+ORDER BY started_at DESC
+LIMIT $2;
+
+-- name: PruneFeedFetches :exec
+DELETE FROM feed_fetches
+WHERE feed_id = $1
+AND id NOT IN (
+    SELECT id
+    FROM feed_fetches
+    WHERE feed_id = $1
+    ORDER BY started_at DESC
+    LIMIT $2
+);
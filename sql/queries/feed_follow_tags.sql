@@ -0,0 +1,9 @@
+-- name: TagFeedFollow :exec
+INSERT INTO feed_follow_tags (feed_follow_id, tag)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UntagFeedFollow :execrows
+DELETE FROM feed_follow_tags
+WHERE feed_follow_id = $1
+AND tag = $2;
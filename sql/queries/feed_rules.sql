@@ -0,0 +1,25 @@
+-- name: CreateFeedRule :one
+INSERT INTO feed_rules (id, created_at, feed_id, user_id, position, action, field, pattern, replacement)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    (SELECT COALESCE(MAX(position), 0) + 1 FROM feed_rules WHERE feed_id = $3),
+    $5,
+    $6,
+    $7,
+    $8
+)
+RETURNING *;
+
+-- name: GetFeedRules :many
+SELECT *
+FROM feed_rules
+WHERE feed_id = $1
+ORDER BY position;
+
+-- name: DeleteFeedRule :execrows
+DELETE FROM feed_rules
+WHERE feed_id = $1
+AND position = $2;
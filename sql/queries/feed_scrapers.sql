@@ -0,0 +1,21 @@
+-- name: UpsertFeedScraper :exec
+INSERT INTO feed_scrapers (feed_id, item_selector, title_selector, link_selector, date_selector, summary_selector)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+ON CONFLICT (feed_id) DO UPDATE
+SET item_selector = EXCLUDED.item_selector,
+title_selector = EXCLUDED.title_selector,
+link_selector = EXCLUDED.link_selector,
+date_selector = EXCLUDED.date_selector,
+summary_selector = EXCLUDED.summary_selector;
+
+-- name: GetFeedScraper :one
+SELECT *
+FROM feed_scrapers
+WHERE feed_id = $1;
@@ -1,17 +1,18 @@
 -- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, kind)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7
 )
 RETURNING *;
 
 -- name: GetAllFeeds :many
-SELECT f.name, f.url, u.name
+SELECT f.name, f.url, u.name, f.link, f.description
 FROM feeds f
 JOIN users u ON f.user_id = u.id;
 
@@ -20,18 +21,150 @@ SELECT id
 FROM feeds
 WHERE url = $1;
 
+-- name: GetFeedByUrl :one
+SELECT *
+FROM feeds
+WHERE url = $1;
+
 -- name: ResetFeeds :exec
 DELETE FROM feeds;
 
 -- name: MarkFeedFetched :one
 UPDATE feeds
-SET last_fetched_at = NOW(),
-updated_at = NOW()
-WHERE id = $1
+SET last_fetched_at = sqlc.arg('fetched_at')::timestamp,
+lease_until = NULL,
+updated_at = sqlc.arg('fetched_at')::timestamp
+WHERE id = sqlc.arg('id')
 returning *;
 
--- name: GetNextFeedToFetch :one
+-- name: ClaimNextFeed :one
+UPDATE feeds
+SET lease_until = NOW() + sqlc.arg('lease_seconds')::int * INTERVAL '1 second'
+WHERE id = (
+    SELECT id
+    FROM feeds
+    WHERE (lease_until IS NULL OR lease_until < NOW())
+    AND EXISTS (
+        SELECT 1
+        FROM feed_follows ff
+        WHERE ff.feed_id = feeds.id
+    )
+    ORDER BY last_fetched_at ASC NULLS FIRST
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ClaimFeed :one
+UPDATE feeds
+SET lease_until = NOW() + sqlc.arg('lease_seconds')::int * INTERVAL '1 second'
+WHERE id = sqlc.arg('id')
+AND (lease_until IS NULL OR lease_until < NOW())
+RETURNING *;
+
+-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET title = $2,
+description = $3,
+link = $4,
+image_url = $5,
+language = $6,
+generator = $7,
+name = COALESCE(NULLIF(name, ''), $2),
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetFeedsToFetch :many
+SELECT *
+FROM feeds
+WHERE (last_fetched_at IS NULL OR last_fetched_at < sqlc.arg('due_before')::timestamp)
+AND EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = feeds.id
+)
+ORDER BY last_fetched_at ASC NULLS FIRST;
+
+-- name: GetFeedQueueLag :one
+SELECT EXTRACT(EPOCH FROM sqlc.arg('now')::timestamp - COALESCE(MIN(COALESCE(last_fetched_at, created_at)), sqlc.arg('now')::timestamp))::float8 AS lag_seconds
+FROM feeds
+WHERE EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = feeds.id
+);
+
+-- name: CountFeeds :one
+SELECT COUNT(*)
+FROM feeds
+WHERE EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = feeds.id
+);
+
+-- name: NotifyFeedAdded :exec
+SELECT pg_notify('gator_feeds', sqlc.arg('url')::text);
+
+-- name: ReleaseFeed :exec
+UPDATE feeds
+SET lease_until = NULL
+WHERE id = $1;
+
+-- name: SetFeedRetention :exec
+UPDATE feeds
+SET retention_max_age_seconds = $2,
+retention_max_posts = $3,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkOrphanedFeeds :execrows
+UPDATE feeds
+SET orphaned_at = $1
+WHERE orphaned_at IS NULL
+AND NOT EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = feeds.id
+);
+
+-- name: ClearOrphanedFeeds :execrows
+UPDATE feeds
+SET orphaned_at = NULL
+WHERE orphaned_at IS NOT NULL
+AND EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = feeds.id
+);
+
+-- name: GetOrphanedFeeds :many
 SELECT *
 FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1;
+WHERE orphaned_at < sqlc.arg('orphaned_before')::timestamp
+AND NOT EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = feeds.id
+)
+ORDER BY orphaned_at;
+
+-- name: LockFeed :exec
+SELECT id
+FROM feeds
+WHERE id = $1
+FOR UPDATE;
+
+-- name: DeleteFeed :execrows
+DELETE FROM feeds
+WHERE id = $1
+AND NOT EXISTS (
+    SELECT 1
+    FROM posts p
+    WHERE p.feed_id = feeds.id
+)
+AND NOT EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = feeds.id
+);
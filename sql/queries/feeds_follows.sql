@@ -14,14 +14,38 @@ inner join
 inner join
     feeds f on inserted_feed_follow.feed_id = f.id;
 
+-- name: GetFeedFollow :one
+SELECT * FROM feed_follows
+WHERE user_id = $1
+AND feed_id = $2;
+
 -- name: GetFeedFollowsForUser :many
-select f.name as feed_name, u.name as user_name
+select coalesce(feed_follows.title, f.name) as feed_name, u.name as user_name, t.tag,
+    (
+        select count(*)
+        from posts p
+        where p.feed_id = f.id
+        and not exists (
+            select 1
+            from post_reads pr
+            where pr.post_id = p.id
+            and pr.user_id = feed_follows.user_id
+        )
+    ) as unread_count
 from feed_follows
 inner join
     users u on feed_follows.user_id = u.id
 inner join
     feeds f on feed_follows.feed_id = f.id
-where feed_follows.user_id = $1;
+left join
+    feed_follow_tags t on t.feed_follow_id = feed_follows.id
+where feed_follows.user_id = $1
+order by t.tag nulls last, feed_name;
+
+-- name: RenameFeedFollow :exec
+UPDATE feed_follows
+SET title = $2, updated_at = $3
+WHERE id = $1;
 
 -- name: ResetFeedFollow :exec
 DELETE FROM feed_follows;
@@ -31,3 +55,7 @@ DELETE FROM feed_follows
 WHERE user_id = $1
 AND feed_id = $2
 RETURNING user_id;
+
+-- name: DeleteFeedFollows :execrows
+DELETE FROM feed_follows
+WHERE feed_id = $1;
@@ -0,0 +1,37 @@
+-- name: CreatePostCategory :exec
+INSERT INTO post_categories (post_id, category)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeletePostCategories :exec
+DELETE FROM post_categories
+WHERE post_id = $1;
+
+-- name: UpsertAuthor :one
+INSERT INTO authors (id, name, normalized)
+VALUES ($1, $2, $3)
+ON CONFLICT (normalized) DO UPDATE
+SET name = EXCLUDED.name
+RETURNING id;
+
+-- name: CreatePostAuthor :exec
+INSERT INTO post_authors (post_id, author_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeletePostAuthors :exec
+DELETE FROM post_authors
+WHERE post_id = $1;
+
+-- name: GetTopCategoriesForUser :many
+SELECT pc.category, COUNT(*) AS post_count
+FROM post_categories pc
+INNER JOIN posts p ON pc.post_id = p.id
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+WHERE ff.user_id = $1
+GROUP BY pc.category
+ORDER BY post_count DESC, pc.category
+LIMIT $2;
+
+-- name: ResetAuthors :exec
+DELETE FROM authors;
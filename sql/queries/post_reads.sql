@@ -0,0 +1,31 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: MarkPostUnread :execrows
+DELETE FROM post_reads
+WHERE user_id = $1
+AND post_id = $2;
+
+-- name: MarkAllRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT ff.user_id, p.id, sqlc.arg('read_at')
+FROM posts p
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+INNER JOIN feeds f ON p.feed_id = f.id
+WHERE ff.user_id = sqlc.arg('user_id')
+AND (sqlc.narg('feed_url')::text IS NULL OR f.url = sqlc.narg('feed_url'))
+ON CONFLICT DO NOTHING;
+
+-- name: GetUnreadPosts :many
+SELECT p.id, p.published_at, p.created_at
+FROM posts p
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+INNER JOIN feeds f ON p.feed_id = f.id
+WHERE ff.user_id = sqlc.arg('user_id')
+AND (sqlc.narg('feed_url')::text IS NULL OR f.url = sqlc.narg('feed_url'))
+AND NOT EXISTS (
+    SELECT 1 FROM post_reads pr
+    WHERE pr.user_id = ff.user_id AND pr.post_id = p.id
+);
@@ -0,0 +1,18 @@
+-- name: StarPost :exec
+INSERT INTO post_stars (user_id, post_id, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: UnstarPost :execrows
+DELETE FROM post_stars
+WHERE user_id = $1
+AND post_id = $2;
+
+-- name: GetStarredPosts :many
+SELECT ps.post_id, ps.created_at AS starred_at, p.title, p.url, p.description, p.published_at, COALESCE(ff.title, f.name) AS feed_name, f.url AS feed_url
+FROM post_stars ps
+INNER JOIN posts p ON ps.post_id = p.id
+INNER JOIN feeds f ON p.feed_id = f.id
+LEFT JOIN feed_follows ff ON ff.feed_id = p.feed_id AND ff.user_id = ps.user_id
+WHERE ps.user_id = $1
+ORDER BY ps.created_at DESC;
@@ -12,8 +12,163 @@ VALUES (
 );
 
 -- name: GetPostsForUser :many
-SELECT p.*
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, p.guid,
+    COALESCE(ff.title, f.name) AS feed_name
 FROM posts p
 INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
-WHERE ff.user_id = $1
+INNER JOIN feeds f ON p.feed_id = f.id
+WHERE ff.user_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('category')::text IS NULL
+    OR EXISTS (
+        SELECT 1
+        FROM post_categories pc
+        WHERE pc.post_id = p.id
+        AND pc.category = sqlc.narg('category')
+    )
+)
+AND (
+    sqlc.narg('author')::text IS NULL
+    OR EXISTS (
+        SELECT 1
+        FROM post_authors pa
+        INNER JOIN authors a ON pa.author_id = a.id
+        WHERE pa.post_id = p.id
+        AND a.normalized = sqlc.narg('author')
+    )
+)
+AND (
+    NOT sqlc.arg('unread_only')::boolean
+    OR NOT EXISTS (
+        SELECT 1
+        FROM post_reads pr
+        WHERE pr.post_id = p.id
+        AND pr.user_id = sqlc.arg('user_id')
+    )
+)
+AND (
+    sqlc.narg('tag')::text IS NULL
+    OR EXISTS (
+        SELECT 1
+        FROM feed_follow_tags t
+        WHERE t.feed_follow_id = ff.id
+        AND t.tag = sqlc.narg('tag')
+    )
+)
 ORDER BY p.published_at DESC;
+
+-- name: UpsertPost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+ON CONFLICT (url) DO UPDATE
+SET title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    published_at = EXCLUDED.published_at,
+    guid = EXCLUDED.guid,
+    updated_at = EXCLUDED.updated_at
+WHERE posts.feed_id = EXCLUDED.feed_id
+AND (
+    posts.title IS DISTINCT FROM EXCLUDED.title
+    OR posts.description IS DISTINCT FROM EXCLUDED.description
+    OR posts.published_at IS DISTINCT FROM EXCLUDED.published_at
+    OR posts.guid IS DISTINCT FROM EXCLUDED.guid
+)
+RETURNING id, (xmax = 0)::boolean AS inserted;
+
+-- name: ResetPosts :exec
+DELETE FROM posts;
+
+-- name: GetPostsToPrune :many
+SELECT id, feed_id, feed_url
+FROM (
+    SELECT p.id,
+        p.feed_id,
+        f.url AS feed_url,
+        p.created_at,
+        COALESCE(f.retention_max_age_seconds, sqlc.narg('default_max_age_seconds')::bigint) AS max_age_seconds,
+        COALESCE(f.retention_max_posts, sqlc.narg('default_max_posts')::int) AS max_posts,
+        ROW_NUMBER() OVER (PARTITION BY p.feed_id ORDER BY p.created_at DESC, p.id) AS position
+    FROM posts p
+    INNER JOIN feeds f ON p.feed_id = f.id
+    WHERE NOT EXISTS (
+        SELECT 1
+        FROM post_stars ps
+        WHERE ps.post_id = p.id
+    )
+) ranked
+WHERE (max_age_seconds IS NOT NULL AND created_at < sqlc.arg('now')::timestamp - max_age_seconds * INTERVAL '1 second')
+OR (max_posts IS NOT NULL AND position > max_posts)
+ORDER BY feed_url, created_at;
+
+-- name: DeletePosts :execrows
+DELETE FROM posts p
+WHERE p.id = ANY(sqlc.arg('ids')::uuid[])
+AND NOT EXISTS (
+    SELECT 1
+    FROM post_stars ps
+    WHERE ps.post_id = p.id
+);
+
+-- name: DeleteFeedPosts :execrows
+DELETE FROM posts p
+WHERE p.feed_id = $1
+AND NOT EXISTS (
+    SELECT 1
+    FROM post_stars ps
+    WHERE ps.post_id = p.id
+)
+AND NOT EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = p.feed_id
+);
+
+-- name: SearchPosts :many
+SELECT m.id,
+    m.title,
+    m.url,
+    m.published_at,
+    m.feed_name,
+    m.rank,
+    ts_headline('english', COALESCE(m.title, ''), m.query, 'HighlightAll=true, StartSel=**, StopSel=**') AS title_headline,
+    ts_headline('english', COALESCE(m.description, ''), m.query, 'MaxFragments=2, MaxWords=20, MinWords=8, StartSel=**, StopSel=**') AS headline
+FROM (
+    SELECT p.id,
+        p.title,
+        p.url,
+        p.description,
+        p.published_at,
+        COALESCE(ff.title, f.name) AS feed_name,
+        q.query,
+        ts_rank_cd(p.search, q.query)::float8 AS rank
+    FROM posts p
+    INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+    INNER JOIN feeds f ON p.feed_id = f.id
+    CROSS JOIN to_tsquery('english', sqlc.arg('query')) AS q(query)
+    WHERE ff.user_id = sqlc.arg('user_id')
+    AND p.search @@ q.query
+    ORDER BY rank DESC, p.published_at DESC
+    LIMIT sqlc.arg('limit')
+) m
+ORDER BY m.rank DESC, m.published_at DESC;
+
+-- name: GetPostByUrl :one
+SELECT id
+FROM posts
+WHERE url = $1;
+
+-- name: GetPostsByIdPrefix :many
+SELECT id
+FROM posts
+WHERE id::text LIKE sqlc.arg('prefix')::text || '%'
+LIMIT 2;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE post_categories(
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    category TEXT NOT NULL,
+    PRIMARY KEY (post_id, category)
+);
+
+CREATE INDEX post_categories_category_idx ON post_categories(category);
+
+CREATE TABLE authors(
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    normalized TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE post_authors(
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+    PRIMARY KEY (post_id, author_id)
+);
+
+-- +goose Down
+DROP TABLE post_authors;
+DROP TABLE authors;
+DROP TABLE post_categories;
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD title TEXT,
+ADD description TEXT,
+ADD link TEXT,
+ADD image_url TEXT,
+ADD language TEXT,
+ADD generator TEXT;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN title,
+DROP COLUMN description,
+DROP COLUMN link,
+DROP COLUMN image_url,
+DROP COLUMN language,
+DROP COLUMN generator;
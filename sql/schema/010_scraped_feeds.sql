@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD kind TEXT NOT NULL DEFAULT 'rss';
+
+CREATE TABLE feed_scrapers(
+    feed_id UUID PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+    item_selector TEXT NOT NULL,
+    title_selector TEXT NOT NULL DEFAULT '',
+    link_selector TEXT NOT NULL DEFAULT '',
+    date_selector TEXT NOT NULL DEFAULT '',
+    summary_selector TEXT NOT NULL DEFAULT ''
+);
+
+-- +goose Down
+DROP TABLE feed_scrapers;
+
+ALTER TABLE feeds
+DROP COLUMN kind;
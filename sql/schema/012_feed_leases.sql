@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD lease_until TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN lease_until;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD retention_max_age_seconds BIGINT,
+ADD retention_max_posts INTEGER;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN retention_max_age_seconds,
+DROP COLUMN retention_max_posts;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD orphaned_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN orphaned_at;
@@ -0,0 +1,8 @@
+package schema
+
+import "embed"
+
+// Migrations holds the goose migrations in this directory so gator can apply them itself
+//
+//go:embed *.sql
+var Migrations embed.FS